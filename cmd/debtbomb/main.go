@@ -1,13 +1,19 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/jobin-404/debtbomb/internal/clock"
 	"github.com/jobin-404/debtbomb/internal/config"
 	"github.com/jobin-404/debtbomb/internal/engine"
+	"github.com/jobin-404/debtbomb/internal/gitinfo"
 	"github.com/jobin-404/debtbomb/internal/jira"
 	"github.com/jobin-404/debtbomb/internal/model"
 	"github.com/jobin-404/debtbomb/internal/notify"
@@ -33,6 +39,8 @@ func main() {
 		runCheck()
 	case "list":
 		runList()
+	case "lint":
+		runLint()
 	case "report":
 		runReport()
 	case "notify":
@@ -45,25 +53,65 @@ func main() {
 }
 
 func printUsage() {
-	fmt.Println("Usage: debtbomb <command> [flags]")
+	fmt.Println("Usage: debtbomb <command> [flags] [paths...]")
 	fmt.Println("Commands:")
 	fmt.Println("  check   Scan for expired debtbombs and exit 1 if found")
 	fmt.Println("  list    List all debtbombs")
+	fmt.Println("  lint    Report malformed debtbombs and exit 1 if found")
 	fmt.Println("  report  Show aggregated statistics about technical debt")
 	fmt.Println("  notify  Notify about expired or expiring debtbombs")
 }
 
 func runCheck() {
 	checkCmd := flag.NewFlagSet("check", flag.ExitOnError)
-	jsonOutput := checkCmd.Bool("json", false, "Output in JSON format")
+	jsonOutput := checkCmd.Bool("json", false, "Output in JSON format (same as --format json)")
+	format := checkCmd.String("format", "text", "Output format: text, json, sarif or junit")
+	junitGroup := checkCmd.String("junit-group", output.JUnitByOwner, "With --format junit, group bombs into suites by owner or folder")
+	junitSkipWarnings := checkCmd.Bool("junit-skip-warnings", false, "With --format junit, report bombs within --warn-in-days as skipped")
 	warnDays := checkCmd.Int("warn-in-days", 0, "Warn about bombs expiring within N days")
+	allowMalformed := checkCmd.Bool("allow-malformed", false, "Do not fail on malformed debtbombs")
+	noGit := checkCmd.Bool("no-git", false, "Skip git blame enrichment (faster)")
+	changedSince := checkCmd.String("changed-since", "", "Only scan files changed since the branch diverged from this git ref")
+	addedLines := checkCmd.Bool("added-lines", false, "With --changed-since, only check bombs on added or modified lines")
+	strict := checkCmd.Bool("strict", false, "Fail when files could not be scanned")
+	verbose := checkCmd.Bool("verbose", false, "List files that could not be scanned")
+	sf := addScanFlags(checkCmd)
 	checkCmd.Parse(os.Args[2:])
 
-	bombs, err := engine.Run(".")
+	if *addedLines && *changedSince == "" {
+		fmt.Fprintln(os.Stderr, "--added-lines requires --changed-since")
+		os.Exit(1)
+	}
+	if *jsonOutput {
+		if *format != "text" && *format != "json" {
+			fmt.Fprintf(os.Stderr, "--json cannot be combined with --format %s\n", *format)
+			os.Exit(1)
+		}
+		*format = "json"
+	}
+	switch *format {
+	case "text", "json", "sarif", "junit":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown --format %q: expected text, json, sarif or junit\n", *format)
+		os.Exit(1)
+	}
+	if *junitGroup != output.JUnitByOwner && *junitGroup != output.JUnitByFolder {
+		fmt.Fprintf(os.Stderr, "Unknown --junit-group %q: expected owner or folder\n", *junitGroup)
+		os.Exit(1)
+	}
+
+	cfg := loadConfig(*sf.root)
+	opts := sf.options(cfg)
+	opts.Git = !*noGit
+	opts.ChangedSince = *changedSince
+	opts.AddedLinesOnly = *addedLines
+	result, err := sf.run(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
 		os.Exit(1)
 	}
+	result.Diagnostics = append(result.Diagnostics, cfg.Attributes.Check(result.Bombs)...)
+	bombs := result.Bombs
 
 	var expired []model.DebtBomb
 	var warning []model.DebtBomb
@@ -76,13 +124,12 @@ func runCheck() {
 
 	// Check for warning window
 	if *warnDays > 0 {
-		today := time.Now().Truncate(24 * time.Hour)
-		warningDate := today.AddDate(0, 0, *warnDays)
+		now := opts.Clock.Now()
 
 		for _, b := range bombs {
 			if !b.IsExpired {
-				// If expire date is before or equal to warning date
-				if !b.Expire.After(warningDate) {
+				// If expire date is within the warning window
+				if b.DaysLeft(now) <= *warnDays {
 					warning = append(warning, b)
 				}
 			}
@@ -90,38 +137,146 @@ func runCheck() {
 	}
 
 	hasExpired := len(expired) > 0
+	incomplete := *strict && len(result.Skipped) > 0
+	failed := hasExpired || (len(result.Diagnostics) > 0 && !*allowMalformed) || incomplete
 
-	if *jsonOutput {
-		output.PrintJSON(bombs)
-		if hasExpired {
+	if *verbose || incomplete {
+		output.PrintSkipped(result.Skipped)
+	}
+
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		reportToGitHub(opts, bombs, expired, warning, result.Diagnostics, output.GitHubOptions{
+			WarnDays:       *warnDays,
+			AllowMalformed: *allowMalformed,
+		}, *format == "text")
+	}
+
+	switch *format {
+	case "json":
+		output.PrintJSON(bombs, result.Diagnostics, result.Skipped)
+	case "sarif":
+		output.PrintSARIF(expired, warning, result.Diagnostics, output.SARIFOptions{
+			WarnDays:       *warnDays,
+			AllowMalformed: *allowMalformed,
+			Path:           repositoryPath(opts),
+		})
+	case "junit":
+		output.PrintJUnit(bombs, warning, result.Diagnostics, output.JUnitOptions{
+			GroupBy:        *junitGroup,
+			WarnDays:       *warnDays,
+			SkipWarnings:   *junitSkipWarnings,
+			AllowMalformed: *allowMalformed,
+		})
+	}
+	if *format != "text" {
+		if failed {
 			os.Exit(1)
 		}
 		os.Exit(0)
 	}
 
-	if hasExpired || len(warning) > 0 {
-		output.PrintCheckReport(expired, warning, *warnDays)
-		if hasExpired {
+	if hasExpired || len(warning) > 0 || len(result.Diagnostics) > 0 {
+		output.PrintCheckReport(expired, warning, *warnDays, result.Diagnostics)
+		if failed {
 			os.Exit(1)
 		}
 		// If only warnings, exit 0
 		os.Exit(0)
 	}
 
+	if incomplete {
+		os.Exit(1)
+	}
+
 	os.Exit(0)
 }
 
+// reportToGitHub annotates the pull request diff through workflow commands
+// and adds a job summary. Annotations go to stderr unless stdout is plain
+// text, so that JSON, SARIF and JUnit output stays parseable.
+func reportToGitHub(opts engine.Options, bombs, expired, warning []model.DebtBomb, diagnostics []model.Diagnostic, gh output.GitHubOptions, textOutput bool) {
+	annotations := os.Stderr
+	if textOutput {
+		annotations = os.Stdout
+	}
+	gh.Path = repositoryPath(opts)
+	output.WriteGitHubAnnotations(annotations, expired, warning, diagnostics, gh)
+
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return
+	}
+	// Other steps append to the same file
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot write the job summary: %v\n", err)
+		return
+	}
+	defer f.Close()
+	output.WriteGitHubSummary(f, bombs, warning, gh.WarnDays, diagnostics)
+}
+
+// repositoryPath returns a function that maps reported paths, which are
+// relative to --base, to paths from the root of the git repository. Paths
+// are kept as they are outside a repository.
+func repositoryPath(opts engine.Options) func(string) string {
+	keep := func(file string) string { return file }
+	top, err := gitinfo.TopLevel(context.Background(), opts.RootPath)
+	if err != nil {
+		return keep
+	}
+	base, err := filepath.Abs(opts.Base)
+	if err != nil {
+		return keep
+	}
+	// git reports the top level with symlinks resolved
+	if resolved, err := filepath.EvalSymlinks(base); err == nil {
+		base = resolved
+	}
+	return func(file string) string {
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		rel, err := filepath.Rel(top, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return file
+		}
+		return rel
+	}
+}
+
 func runList() {
 	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
 	expiredOnly := listCmd.Bool("expired", false, "Show only expired bombs")
 	jsonOutput := listCmd.Bool("json", false, "Output in JSON format")
+	ndjsonOutput := listCmd.Bool("ndjson", false, "Output one JSON object per bomb as soon as it is found, unsorted")
+	var attrFilters attrList
+	var tagFilters stringList
+	listCmd.Var(&attrFilters, "attr", "Show only bombs with attribute key=value (repeatable)")
+	listCmd.Var(&tagFilters, "tag", "Show only bombs with the given tag (repeatable)")
+	noGit := listCmd.Bool("no-git", false, "Skip git blame enrichment (faster)")
+	verbose := listCmd.Bool("verbose", false, "List files that could not be scanned")
+	sf := addScanFlags(listCmd)
 	listCmd.Parse(os.Args[2:])
 
-	bombs, err := engine.Run(".")
+	if *jsonOutput && *ndjsonOutput {
+		fmt.Fprintln(os.Stderr, "--json and --ndjson cannot be combined")
+		os.Exit(1)
+	}
+
+	opts := sf.options(loadConfig(*sf.root))
+	opts.Git = !*noGit
+	if *ndjsonOutput {
+		listStream(sf, opts, *expiredOnly, attrFilters, tagFilters, *verbose)
+		return
+	}
+	result, err := sf.run(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
 		os.Exit(1)
 	}
+	bombs := result.Bombs
 
 	if *expiredOnly {
 		var expired []model.DebtBomb
@@ -133,25 +288,123 @@ func runList() {
 		bombs = expired
 	}
 
+	if len(attrFilters) > 0 || len(tagFilters) > 0 {
+		var matched []model.DebtBomb
+		for _, b := range bombs {
+			if matchesFilters(b, attrFilters, tagFilters) {
+				matched = append(matched, b)
+			}
+		}
+		bombs = matched
+	}
+
+	if *verbose {
+		output.PrintSkipped(result.Skipped)
+	}
+
 	if *jsonOutput {
-		output.PrintJSON(bombs)
+		output.PrintJSON(bombs, result.Diagnostics, result.Skipped)
 	} else {
-		output.PrintTable(bombs)
+		output.PrintTable(bombs, opts.Clock)
+	}
+}
+
+// listStream prints each matching bomb as a line of JSON while the scan is
+// still running
+func listStream(sf scanFlags, opts engine.Options, expiredOnly bool, attrFilters, tagFilters []string, verbose bool) {
+	bombs := make(chan model.DebtBomb, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for b := range bombs {
+			if expiredOnly && !b.IsExpired {
+				continue
+			}
+			if !matchesFilters(b, attrFilters, tagFilters) {
+				continue
+			}
+			if err := output.WriteNDJSONBomb(os.Stdout, b); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to encode json: %v\n", err)
+			}
+		}
+	}()
+
+	summary, err := sf.stream(opts, bombs)
+	<-done
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
+		os.Exit(1)
+	}
+	if verbose {
+		output.PrintSkipped(summary.Skipped)
+	}
+}
+
+func runLint() {
+	lintCmd := flag.NewFlagSet("lint", flag.ExitOnError)
+	jsonOutput := lintCmd.Bool("json", false, "Output in JSON format")
+	verbose := lintCmd.Bool("verbose", false, "List files that could not be scanned")
+	sf := addScanFlags(lintCmd)
+	lintCmd.Parse(os.Args[2:])
+
+	cfg := loadConfig(*sf.root)
+	opts := sf.options(cfg)
+	if opts.RelativeBase.IsZero() {
+		// Lint checks the markers, not their dates
+		opts.RelativeBase = opts.Clock.Now()
+	}
+	result, err := sf.run(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
+		os.Exit(1)
+	}
+	result.Diagnostics = append(result.Diagnostics, cfg.Attributes.Check(result.Bombs)...)
+
+	if *verbose {
+		output.PrintSkipped(result.Skipped)
+	}
+
+	if *jsonOutput {
+		output.PrintDiagnosticsJSON(result.Diagnostics)
+	} else if len(result.Diagnostics) > 0 {
+		fmt.Printf("DebtBomb lint: %d malformed\n\n", len(result.Diagnostics))
+		output.PrintDiagnostics(result.Diagnostics)
+	}
+
+	if len(result.Diagnostics) > 0 {
+		os.Exit(1)
 	}
 }
 
 func runReport() {
 	reportCmd := flag.NewFlagSet("report", flag.ExitOnError)
 	jsonOutput := reportCmd.Bool("json", false, "Output in JSON format")
+	var groupBy stringList
+	reportCmd.Var(&groupBy, "group-by", "Also group debt by this attribute (repeatable)")
+	noGit := reportCmd.Bool("no-git", false, "Skip git blame enrichment (faster)")
+	verbose := reportCmd.Bool("verbose", false, "List files that could not be scanned")
+	sf := addScanFlags(reportCmd)
 	reportCmd.Parse(os.Args[2:])
 
-	bombs, err := engine.Run(".")
+	opts := sf.options(loadConfig(*sf.root))
+	opts.Git = !*noGit
+	result, err := sf.run(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
 		os.Exit(1)
 	}
 
-	r := report.Generate(bombs)
+	if *verbose {
+		output.PrintSkipped(result.Skipped)
+	}
+
+	r := report.Generate(result.Bombs, opts.Clock)
+	for _, key := range groupBy {
+		if r.ByAttribute == nil {
+			r.ByAttribute = make(map[string][]report.CountItem)
+		}
+		r.ByAttribute[key] = report.GroupBy(result.Bombs, key)
+	}
 
 	if *jsonOutput {
 		output.PrintReportJSON(r)
@@ -164,17 +417,14 @@ func runNotify() {
 	notifyCmd := flag.NewFlagSet("notify", flag.ExitOnError)
 	expired := notifyCmd.Bool("expired", false, "Process expired bombs")
 	expireInDays := notifyCmd.Int("expire-in-days", 0, "Process bombs expiring in N days")
+	noGit := notifyCmd.Bool("no-git", false, "Skip git blame enrichment (faster)")
+	sf := addScanFlags(notifyCmd)
 	notifyCmd.Parse(os.Args[2:])
 
-	// Load Config
-	cfg, err := config.Load(".")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to load config: %v\n", err)
-		cfg = &config.Config{}
-	}
+	cfg := loadConfig(*sf.root)
 
 	// Load State
-	st, err := state.Load(".")
+	st, err := state.Load(*sf.root)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading state: %v\n", err)
 		os.Exit(0)
@@ -186,21 +436,257 @@ func runNotify() {
 		jClient = jira.NewClient(cfg.Jira.BaseURL, cfg.Jira.Email, cfg.Jira.APIToken)
 	}
 
+	opts := sf.options(cfg)
+	opts.Git = !*noGit
+
 	router := &notify.Router{
 		Config: cfg,
 		Jira:   jClient,
 		State:  st,
+		Clock:  opts.Clock,
 	}
 
-	bombs, err := engine.Run(".")
+	result, err := sf.run(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
 		os.Exit(0)
 	}
 
-	if err := router.SyncAndNotify(bombs, *expireInDays, *expired); err != nil {
+	if err := router.SyncAndNotify(result.Bombs, *expireInDays, *expired); err != nil {
 
 		fmt.Fprintf(os.Stderr, "Error during sync/notify: %v\n", err)
 		os.Exit(0)
 	}
 }
+
+// loadConfig loads .debtbomb/config.toml from root, falling back to an empty config
+func loadConfig(root string) *config.Config {
+	cfg, err := config.Load(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load config: %v\n", err)
+		cfg = &config.Config{}
+	}
+	return cfg
+}
+
+// scanOptions returns the engine options configured in the [scan] section
+// and the time zone
+func scanOptions(cfg *config.Config) engine.Options {
+	loc, err := cfg.Location()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using UTC\n", err)
+		loc = time.UTC
+	}
+	return engine.Options{
+		RootPath:  ".",
+		Excluded:  cfg.Scan.Excluded(),
+		GitIgnore: cfg.Scan.GitIgnore,
+		Workers:   cfg.Scan.Workers,
+		Location:  loc,
+
+		Attributes:        cfg.Attributes.Keys(),
+		IgnoredExtensions: cfg.Scan.IgnoredExtensions(),
+		MaxFileSize:       cfg.Scan.MaxFileSize,
+	}
+}
+
+// scanFlags are the flags shared by the commands that scan
+type scanFlags struct {
+	fs         *flag.FlagSet
+	root       *string
+	base       *string
+	stdin      *bool
+	rev        *string
+	archive    *string
+	timeout    *time.Duration
+	workers    *int
+	today      *string
+	relativeTo *string
+	noCache    *bool
+}
+
+func addScanFlags(fs *flag.FlagSet) scanFlags {
+	return scanFlags{
+		fs:      fs,
+		root:    fs.String("root", ".", "Project root holding .debtbomb/; scanned when no paths are given"),
+		base:    fs.String("base", "", "Report file paths relative to this directory (default: --root)"),
+		stdin:   fs.Bool("stdin", false, "Read the paths to scan from stdin, one per line"),
+		rev:     fs.String("rev", "", "Scan the files as they are in this git revision instead of the work tree"),
+		archive: fs.String("archive", "", "Scan the files in this .zip, .tar, .tar.gz or .tgz instead of the work tree"),
+		timeout: fs.Duration("timeout", 0, "Abort the scan after this long, e.g. 2m (0 means no limit)"),
+		workers: fs.Int("workers", 0, "Number of files parsed in parallel (default: from config, else 2x CPUs)"),
+		today:   fs.String("today", "", "Evaluate expiry as if today were this date (YYYY-MM-DD)"),
+		noCache: fs.Bool("no-cache", false, "Parse every file instead of reusing results cached in .debtbomb/cache"),
+
+		relativeTo: fs.String("relative-to", "", "Count relative expiries like +90d from this date (YYYY-MM-DD) when git history is unavailable"),
+	}
+}
+
+// options returns the configured scan options with the flags applied.
+// The clock is stopped when the command starts, or set to --today, so
+// that every part of the run agrees on the date.
+func (f scanFlags) options(cfg *config.Config) engine.Options {
+	opts := scanOptions(cfg)
+	opts.RootPath = *f.root
+	opts.Base = *f.base
+	if opts.Base == "" {
+		opts.Base = *f.root
+	}
+	paths, err := f.paths()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	opts.Paths = paths
+	if *f.rev != "" && *f.archive != "" {
+		fmt.Fprintln(os.Stderr, "Error: --rev and --archive cannot be combined")
+		os.Exit(1)
+	}
+	opts.Rev = *f.rev
+	opts.Archive = *f.archive
+	if *f.workers > 0 {
+		opts.Workers = *f.workers
+	}
+	opts.Cache = !*f.noCache
+
+	opts.Clock = clock.Fixed(time.Now())
+	if *f.today != "" {
+		today, err := clock.ParseDay(*f.today, opts.Location)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --today %q: expected YYYY-MM-DD\n", *f.today)
+			os.Exit(1)
+		}
+		opts.Clock = clock.Fixed(today)
+	}
+	if *f.relativeTo != "" {
+		base, err := clock.ParseDay(*f.relativeTo, opts.Location)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --relative-to %q: expected YYYY-MM-DD\n", *f.relativeTo)
+			os.Exit(1)
+		}
+		opts.RelativeBase = base
+	}
+	return opts
+}
+
+// paths returns the path arguments, or with --stdin the paths read from
+// stdin, relative to --root. nil means the whole root. Paths on stdin
+// that do not exist are ignored, so deleted files in a diff do no harm.
+// With --rev or --archive the paths name files in there and need not
+// exist in the work tree.
+func (f scanFlags) paths() ([]string, error) {
+	args := f.fs.Args()
+	if *f.stdin {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("--stdin cannot be combined with path arguments")
+		}
+		args = []string{}
+		lines := bufio.NewScanner(os.Stdin)
+		for lines.Scan() {
+			if line := strings.TrimSpace(lines.Text()); line != "" {
+				args = append(args, line)
+			}
+		}
+		if err := lines.Err(); err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+	} else if len(args) == 0 {
+		return nil, nil
+	} else if *f.rev == "" && *f.archive == "" {
+		for _, arg := range args {
+			if _, err := os.Stat(arg); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	root, err := filepath.Abs(*f.root)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(args))
+	for _, arg := range args {
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is outside the root %s", arg, *f.root)
+		}
+		paths = append(paths, rel)
+	}
+	return paths, nil
+}
+
+// run scans within the --timeout limit
+func (f scanFlags) run(opts engine.Options) (*engine.Result, error) {
+	ctx := context.Background()
+	if *f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *f.timeout)
+		defer cancel()
+	}
+	return engine.Run(ctx, opts)
+}
+
+// stream scans within the --timeout limit, sending bombs as they are found
+func (f scanFlags) stream(opts engine.Options, bombs chan<- model.DebtBomb) (*engine.Summary, error) {
+	ctx := context.Background()
+	if *f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *f.timeout)
+		defer cancel()
+	}
+	return engine.Stream(ctx, opts, bombs)
+}
+
+// stringList is a flag that can be given more than once
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// attrList is a flag of key=value pairs that can be given more than once
+type attrList []string
+
+func (a *attrList) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *attrList) Set(value string) error {
+	if key, _, ok := strings.Cut(value, "="); !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*a = append(*a, value)
+	return nil
+}
+
+// matchesFilters reports whether a bomb has every key=value attribute and every tag
+func matchesFilters(b model.DebtBomb, attrs, tags []string) bool {
+	for _, f := range attrs {
+		key, value, _ := strings.Cut(f, "=")
+		if b.Attribute(key) != value {
+			return false
+		}
+	}
+	for _, tag := range tags {
+		found := false
+		for _, t := range b.Tags() {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -4,4 +4,4 @@ go 1.21
 
 require github.com/BurntSushi/toml v1.3.2
 
-require github.com/joho/godotenv v1.5.1 // indirect
+require github.com/joho/godotenv v1.5.1
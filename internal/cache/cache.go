@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+// format changes whenever the layout of the cache file changes
+const format = 1
+
+// Entry is what was found in one file the last time it was parsed
+type Entry struct {
+	Size        int64              `json:"size"`
+	ModTime     int64              `json:"mtime"`
+	Hash        string             `json:"hash"`
+	Bombs       []model.DebtBomb   `json:"bombs,omitempty"`
+	Diagnostics []model.Diagnostic `json:"diagnostics,omitempty"`
+	Skipped     *model.SkippedFile `json:"skipped,omitempty"`
+}
+
+// Cache maps file paths, as handed to the parser, to parse results. Bomb
+// IDs include the path, so a file scanned under another name is parsed
+// again. Cache is safe for concurrent use.
+type Cache struct {
+	path    string
+	version string
+
+	// written is the mtime of the cache file. A file modified in the same
+	// clock tick could have changed after it was parsed without changing
+	// its mtime, so entries at least as new are checked by content.
+	written int64
+
+	mu      sync.Mutex
+	entries map[string]Entry
+	seen    map[string]bool
+	dirty   bool
+}
+
+type cacheFile struct {
+	Format  int              `json:"format"`
+	Version string           `json:"version"`
+	Files   map[string]Entry `json:"files"`
+}
+
+// Load reads the cache of root from .debtbomb/cache, which Save fills
+// together with a .gitignore so that it is never committed. Entries
+// written by a different parser version, or a cache that cannot be read,
+// are discarded.
+func Load(root, version string) *Cache {
+	c := &Cache{
+		path:    filepath.Join(root, ".debtbomb", "cache", "scan.json"),
+		version: version,
+		entries: make(map[string]Entry),
+		seen:    make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return c
+	}
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return c
+	}
+	c.written = info.ModTime().UnixNano()
+	var file cacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return c
+	}
+	if file.Format != format || file.Version != version || file.Files == nil {
+		c.dirty = true
+		return c
+	}
+	c.entries = file.Files
+	return c
+}
+
+// Get returns the entry for path if the file still has the same size and
+// modification time, and that time is older than the cache, like git's
+// check for racily clean index entries
+func (c *Cache) Get(path string, size, modTime int64) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[path]
+	if !ok || e.Size != size || e.ModTime != modTime || modTime >= c.written {
+		return Entry{}, false
+	}
+	c.seen[path] = true
+	return e, true
+}
+
+// GetByHash returns the entry for path if the content is unchanged. This
+// catches files that were touched, or checked out again, without edits.
+func (c *Cache) GetByHash(path, hash string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[path]
+	if !ok || e.Hash != hash {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Put stores the entry for path
+func (c *Cache) Put(path string, e Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[path] = e
+	c.seen[path] = true
+	c.dirty = true
+}
+
+// Save writes the cache if it changed. With prune, entries for files that
+// were not looked up or stored since Load are dropped; use it after a scan
+// of the whole tree.
+func (c *Cache) Save(prune bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if prune {
+		for path := range c.entries {
+			if !c.seen[path] {
+				delete(c.entries, path)
+				c.dirty = true
+			}
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(cacheFile{Format: format, Version: c.version, Files: c.entries})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n"), 0644); err != nil {
+		return err
+	}
+
+	// Write then rename so that an interrupted run never leaves a torn file
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// Hash returns the content hash stored in entries
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+func TestCacheRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	c := Load(root, "1")
+	c.Put("a.go", Entry{Size: 10, ModTime: 20, Hash: Hash([]byte("a")), Bombs: []model.DebtBomb{{ID: "x"}}})
+	c.Put("gone.go", Entry{Size: 1, ModTime: 1})
+	if err := c.Save(false); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	c = Load(root, "1")
+	if e, ok := c.Get("a.go", 10, 20); !ok || len(e.Bombs) != 1 {
+		t.Fatalf("Expected a hit for a.go, got %+v, %v", e, ok)
+	}
+	if _, ok := c.Get("a.go", 10, 21); ok {
+		t.Errorf("Expected a miss after the mtime changed")
+	}
+	if _, ok := c.GetByHash("a.go", Hash([]byte("a"))); !ok {
+		t.Errorf("Expected a hit for unchanged content")
+	}
+
+	// Pruning drops the entries that were not used
+	if err := c.Save(true); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	c = Load(root, "1")
+	if _, ok := c.Get("gone.go", 1, 1); ok {
+		t.Errorf("Expected gone.go to be pruned")
+	}
+	if _, ok := c.Get("a.go", 10, 20); !ok {
+		t.Errorf("Expected a.go to be kept")
+	}
+}
+
+func TestLoadDiscardsOtherParserVersion(t *testing.T) {
+	root := t.TempDir()
+	c := Load(root, "1")
+	c.Put("a.go", Entry{Size: 10, ModTime: 20})
+	if err := c.Save(false); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if _, ok := Load(root, "2").Get("a.go", 10, 20); ok {
+		t.Errorf("Expected entries of another parser version to be discarded")
+	}
+}
+
+func TestGetMissesRacyEntries(t *testing.T) {
+	root := t.TempDir()
+	c := Load(root, "1")
+	c.Put("a.go", Entry{Size: 10, ModTime: 20, Hash: Hash([]byte("a"))})
+	if err := c.Save(false); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// The file was modified in the same tick as the cache was written
+	info, err := os.Stat(filepath.Join(root, ".debtbomb", "cache", "scan.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	racy := info.ModTime().UnixNano()
+	c = Load(root, "1")
+	c.Put("a.go", Entry{Size: 10, ModTime: racy, Hash: Hash([]byte("a"))})
+
+	if _, ok := c.Get("a.go", 10, racy); ok {
+		t.Errorf("Expected a miss for an entry as new as the cache")
+	}
+	if _, ok := c.GetByHash("a.go", Hash([]byte("a"))); !ok {
+		t.Errorf("Expected the content check to still hit")
+	}
+}
@@ -0,0 +1,45 @@
+package clock
+
+import "time"
+
+// Clock tells the time. A run uses a single clock so that the engine,
+// check, notify, report and output agree on what "today" is.
+type Clock interface {
+	Now() time.Time
+}
+
+type system struct{}
+
+func (system) Now() time.Time {
+	return time.Now()
+}
+
+// System returns the wall clock
+func System() Clock {
+	return system{}
+}
+
+type fixed time.Time
+
+func (c fixed) Now() time.Time {
+	return time.Time(c)
+}
+
+// Fixed returns a clock that is stopped at t
+func Fixed(t time.Time) Clock {
+	return fixed(t)
+}
+
+// OrSystem returns c, or the wall clock when c is nil
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System()
+	}
+	return c
+}
+
+// ParseDay parses a YYYY-MM-DD date as used by --today and returns the
+// start of that day in loc
+func ParseDay(s string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, loc)
+}
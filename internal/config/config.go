@@ -4,14 +4,25 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/jobin-404/debtbomb/internal/model"
+	"github.com/jobin-404/debtbomb/internal/scanner"
 )
 
 type Config struct {
-	Jira   JiraConfig        `toml:"jira"`
-	Owners map[string]string `toml:"owners"`
-	Notify []NotifyConfig    `toml:"notify"`
+	Jira       JiraConfig        `toml:"jira"`
+	Owners     map[string]string `toml:"owners"`
+	Notify     []NotifyConfig    `toml:"notify"`
+	Attributes AttributesConfig  `toml:"attributes"`
+	Templates  TemplatesConfig   `toml:"templates"`
+	Scan       ScanConfig        `toml:"scan"`
+	// TimeZone is the IANA time zone that expire dates are in, e.g.
+	// "America/Los_Angeles", or "Local". It defaults to UTC.
+	TimeZone string `toml:"timezone"`
 }
 
 type JiraConfig struct {
@@ -28,6 +39,77 @@ type NotifyConfig struct {
 	Days int    `toml:"days"`
 }
 
+// AttributesConfig declares the custom attributes a project expects.
+// When Allowed is empty any custom attribute is accepted.
+type AttributesConfig struct {
+	Allowed  []string `toml:"allowed"`
+	Required []string `toml:"required"`
+}
+
+// TemplatesConfig overrides the text of notifications and Jira tickets.
+// Templates use text/template syntax; see notify.TemplateData.
+type TemplatesConfig struct {
+	Expired         string `toml:"expired"`
+	ExpiringSoon    string `toml:"expiring_soon"`
+	JiraSummary     string `toml:"jira_summary"`
+	JiraDescription string `toml:"jira_description"`
+}
+
+// ScanConfig controls which files are scanned
+type ScanConfig struct {
+	// GitIgnore scans only the files git tracks or would track, so that
+	// .gitignore files are honored
+	GitIgnore bool `toml:"gitignore"`
+	// DefaultExcludes set to false drops the built-in directory exclusions
+	DefaultExcludes *bool `toml:"default_excludes"`
+	// Include removes directory names from the built-in exclusions
+	Include []string `toml:"include"`
+	// Exclude adds directory names to the exclusions
+	Exclude []string `toml:"exclude"`
+	// IncludeExtensions removes file suffixes, e.g. ".log", from the
+	// built-in skipped extensions
+	IncludeExtensions []string `toml:"include_extensions"`
+	// ExcludeExtensions adds file suffixes to skip, e.g. ".sql" or ".bundle.js"
+	ExcludeExtensions []string `toml:"exclude_extensions"`
+	// MaxFileSize is the size in bytes above which files are skipped; 0
+	// means 1 MiB and -1 means no limit
+	MaxFileSize int64 `toml:"max_file_size"`
+	// Workers is the number of files parsed in parallel; 0 picks a default
+	Workers int `toml:"workers"`
+}
+
+// Excluded returns the directory names to skip
+func (s ScanConfig) Excluded() []string {
+	excluded := []string{}
+	if s.DefaultExcludes == nil || *s.DefaultExcludes {
+		included := make(map[string]bool)
+		for _, dir := range s.Include {
+			included[dir] = true
+		}
+		for _, dir := range scanner.DefaultExcluded() {
+			if !included[dir] {
+				excluded = append(excluded, dir)
+			}
+		}
+	}
+	return append(excluded, s.Exclude...)
+}
+
+// IgnoredExtensions returns the file suffixes to skip
+func (s ScanConfig) IgnoredExtensions() []string {
+	included := make(map[string]bool)
+	for _, ext := range s.IncludeExtensions {
+		included["."+strings.TrimPrefix(strings.ToLower(ext), ".")] = true
+	}
+	ignored := []string{}
+	for _, ext := range scanner.DefaultIgnoredExtensions() {
+		if !included[ext] {
+			ignored = append(ignored, ext)
+		}
+	}
+	return append(ignored, s.ExcludeExtensions...)
+}
+
 func Load(rootPath string) (*Config, error) {
 	configPath := filepath.Join(rootPath, ".debtbomb", "config.toml")
 
@@ -46,6 +128,18 @@ func Load(rootPath string) (*Config, error) {
 	return &conf, nil
 }
 
+// Location returns the configured time zone
+func (c *Config) Location() (*time.Location, error) {
+	if c.TimeZone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", c.TimeZone)
+	}
+	return loc, nil
+}
+
 func (c *Config) GetSlackWebhook() string {
 	return os.Getenv("SLACK_WEBHOOK_URL")
 }
@@ -57,3 +151,51 @@ func (c *Config) GetDiscordWebhook() string {
 func (c *Config) GetTeamsWebhook() string {
 	return os.Getenv("TEAMS_WEBHOOK_URL")
 }
+
+// Keys returns the declared attribute keys, allowed or required
+func (a AttributesConfig) Keys() []string {
+	return append(append([]string(nil), a.Allowed...), a.Required...)
+}
+
+// Check flags custom attributes that are not declared and required
+// attributes that are missing
+func (a AttributesConfig) Check(bombs []model.DebtBomb) []model.Diagnostic {
+	allowed := make(map[string]bool)
+	for _, key := range a.Allowed {
+		allowed[key] = true
+	}
+
+	var diagnostics []model.Diagnostic
+	for _, b := range bombs {
+		var problems []string
+		if len(allowed) > 0 {
+			keys := make([]string, 0, len(b.Attributes))
+			for key := range b.Attributes {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if !allowed[key] {
+					problems = append(problems, fmt.Sprintf("unknown attribute %q", key))
+				}
+			}
+		}
+		for _, key := range a.Required {
+			if b.Attribute(key) == "" {
+				problems = append(problems, fmt.Sprintf("missing required attribute %q", key))
+			}
+		}
+
+		for _, problem := range problems {
+			diagnostics = append(diagnostics, model.Diagnostic{
+				File:    b.File,
+				Line:    b.Line,
+				Column:  b.Column,
+				Cell:    b.Cell,
+				Problem: problem,
+				RawText: b.RawText,
+			})
+		}
+	}
+	return diagnostics
+}
@@ -1,50 +1,335 @@
 package engine
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jobin-404/debtbomb/internal/cache"
+	"github.com/jobin-404/debtbomb/internal/clock"
+	"github.com/jobin-404/debtbomb/internal/format"
+	"github.com/jobin-404/debtbomb/internal/gitinfo"
 	"github.com/jobin-404/debtbomb/internal/model"
 	"github.com/jobin-404/debtbomb/internal/parser"
 	"github.com/jobin-404/debtbomb/internal/scanner"
+	"github.com/jobin-404/debtbomb/internal/vfs"
+	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
-// Run executes the debtbomb scan and returns all found items
-func Run(rootPath string) ([]model.DebtBomb, error) {
+// Result holds everything found by a scan
+type Result struct {
+	Bombs       []model.DebtBomb
+	Diagnostics []model.Diagnostic
+	// Skipped lists the files that could not be scanned, or only in part
+	Skipped []model.SkippedFile
+}
+
+// sniffSize is how much of a file is checked for NUL bytes to detect binaries
+const sniffSize = 8000
+
+// Options controls a scan
+type Options struct {
+	RootPath string
+	// FS, when set, is scanned instead of the files under RootPath. Paths
+	// in it are relative to RootPath and reported as if they were there.
+	FS fs.FS
+	// Rev, when set, scans the files under RootPath as they are in this git
+	// revision, read from the object database instead of the work tree
+	Rev string
+	// Archive, when set, scans the files in this .zip, .tar, .tar.gz or
+	// .tgz file as if they were under RootPath
+	Archive string
+	// Paths, when set, limits the scan to these files and directories,
+	// relative to RootPath
+	Paths []string
+	// Base, when set, makes the reported file paths relative to it instead
+	// of starting with RootPath. Bomb IDs include the path, so keep it the
+	// same across runs.
+	Base string
+	// Excluded lists directory names to skip; nil means scanner.DefaultExcluded
+	Excluded []string
+	// IgnoredExtensions lists file name suffixes to skip; nil means
+	// scanner.DefaultIgnoredExtensions
+	IgnoredExtensions []string
+	// MaxFileSize is the size in bytes above which files are skipped; 0
+	// means scanner.DefaultMaxFileSize and a negative value means no limit
+	MaxFileSize int64
+	// GitIgnore scans the files listed by git instead of walking the tree,
+	// honoring .gitignore. It falls back to walking outside a git work tree
+	// and does not apply to FS, Rev or Archive.
+	GitIgnore bool
+	// Git enriches bombs with author, commit and date from git blame.
+	// It is skipped when RootPath is not inside a git work tree.
+	Git bool
+	// ChangedSince limits the scan to files that changed since the branch
+	// diverged from this git ref, including uncommitted and untracked files
+	ChangedSince string
+	// AddedLinesOnly, together with ChangedSince, keeps only bombs and
+	// diagnostics on added or modified lines
+	AddedLinesOnly bool
+	// Workers is the number of files parsed in parallel; 0 means twice the CPU count
+	Workers int
+	// Clock decides which bombs are expired; nil means the system clock
+	Clock clock.Clock
+	// Location is the time zone of expire dates without a tz attribute;
+	// nil means UTC
+	Location *time.Location
+	// Attributes lists the custom attribute keys accepted on the comment
+	// lines after a bare marker; see parser.Parser
+	Attributes []string
+	// RelativeBase is the date that relative expiries such as +90d count
+	// from when the commit that introduced the bomb is unknown. When it is
+	// zero, those bombs are reported as diagnostics instead.
+	RelativeBase time.Time
+	// Cache reuses the parse results stored in RootPath/.debtbomb/cache for
+	// files that did not change, and updates it after the scan. It only
+	// applies to scans of the work tree.
+	Cache bool
+}
+
+// Run executes the debtbomb scan and returns all found items. The scan
+// stops early when ctx is done or the directory walk fails.
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	var bombs []model.DebtBomb
+	diagnostics, skipped, err := scan(ctx, opts, func(found []model.DebtBomb) error {
+		bombs = append(bombs, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, timedOut(err)
+	}
+
+	sort.Slice(bombs, func(i, j int) bool {
+		if !bombs[i].Expire.Equal(bombs[j].Expire) {
+			return bombs[i].Expire.Before(bombs[j].Expire)
+		}
+		if bombs[i].File != bombs[j].File {
+			return bombs[i].File < bombs[j].File
+		}
+		return bombs[i].Line < bombs[j].Line
+	})
+	diagnostics = append(diagnostics, duplicateIDs(bombs)...)
+	sortDiagnostics(diagnostics)
+
+	return &Result{Bombs: bombs, Diagnostics: diagnostics, Skipped: skipped}, nil
+}
+
+// Summary describes a streamed scan once every bomb has been sent
+type Summary struct {
+	// Bombs and Expired count the bombs that were sent
+	Bombs   int
+	Expired int
+	// Diagnostics includes the duplicate IDs among the sent bombs
+	Diagnostics []model.Diagnostic
+	Skipped     []model.SkippedFile
+}
+
+// Stream executes the scan like Run, but sends each bomb to bombs as soon
+// as its file is parsed, in no particular order, so that memory does not
+// grow with the number of bombs. It closes bombs when the scan ends, before
+// it returns, so callers must wait for Stream to return before using the
+// Summary.
+func Stream(ctx context.Context, opts Options, bombs chan<- model.DebtBomb) (*Summary, error) {
+	defer close(bombs)
+
+	summary := &Summary{}
+	var duplicates []model.Diagnostic
+	seen := make(map[string]model.DebtBomb)
+	diagnostics, skipped, err := scan(ctx, opts, func(found []model.DebtBomb) error {
+		for _, b := range found {
+			if d, ok := duplicateID(seen, b); ok {
+				duplicates = append(duplicates, d)
+			}
+			select {
+			case bombs <- b:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			summary.Bombs++
+			if b.IsExpired {
+				summary.Expired++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, timedOut(err)
+	}
+
+	summary.Diagnostics = append(diagnostics, duplicates...)
+	sortDiagnostics(summary.Diagnostics)
+	summary.Skipped = skipped
+	return summary, nil
+}
+
+// timedOut tells a deadline apart from other errors
+func timedOut(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("scan timed out: %w", err)
+	}
+	return err
+}
+
+// scan parses the files in parallel and passes the bombs of each file,
+// enriched and with their expiry resolved, to found. found is called from
+// one goroutine at a time; an error from it stops the scan.
+func scan(parent context.Context, opts Options, found func([]model.DebtBomb) error) ([]model.Diagnostic, []model.SkippedFile, error) {
+	rootPath := opts.RootPath
+
+	// Canceled on walk errors so that the workers stop early
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	names, err := newPathNames(opts.Base)
+	if err != nil {
+		return nil, nil, err
+	}
+	// nameOf returns the reported path of a path from the scanner
+	nameOf := func(rel string) string {
+		return names.display(filepath.Join(rootPath, filepath.FromSlash(rel)))
+	}
+
+	fsys, err := openFS(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	virtual := fsys != nil
+	if !virtual {
+		fsys = os.DirFS(rootPath)
+	}
+
+	var files []string
+	var changes map[string]gitinfo.Change
+	if opts.ChangedSince != "" {
+		if virtual {
+			return nil, nil, errors.New("--changed-since only applies to the work tree")
+		}
+		changed, err := gitinfo.Changes(ctx, rootPath, opts.ChangedSince)
+		if err != nil {
+			return nil, nil, fmt.Errorf("--changed-since %s: %w", opts.ChangedSince, err)
+		}
+		files = make([]string, 0, len(changed))
+		changes = make(map[string]gitinfo.Change, len(changed))
+		for _, c := range changed {
+			files = append(files, c.Path)
+			changes[names.display(filepath.Join(rootPath, c.Path))] = c
+		}
+	} else if opts.GitIgnore && !virtual {
+		listed, err := gitinfo.ListFiles(ctx, rootPath)
+		if err != nil && err != gitinfo.ErrNotRepository {
+			return nil, nil, err
+		}
+		files = listed
+	}
+	if files != nil && opts.Paths != nil {
+		files = withinPaths(files, opts.Paths)
+	}
+
+	// Archives and other file systems have no history to blame. Without git
+	// the bombs simply carry no blame data.
+	blame := opts.Git && (!virtual || opts.Rev != "") && gitinfo.IsRepository(ctx, rootPath)
+
 	filesChan := make(chan string, 100)
-	resultsChan := make(chan []model.DebtBomb, 100)
+	resultsChan := make(chan parser.Result, 100)
 	errChan := make(chan error, 1)
 
+	var skippedMu sync.Mutex
+	var skipped []model.SkippedFile
+	onSkip := func(s model.SkippedFile) {
+		skippedMu.Lock()
+		defer skippedMu.Unlock()
+		skipped = append(skipped, s)
+	}
+
 	go func() {
-		err := scanner.Scan(scanner.Config{
-			RootPath: rootPath,
-			Excluded: scanner.DefaultExcluded(),
-		}, filesChan)
+		config := scannerConfig(opts)
+		config.FS = fsys
+		config.Files = files
+		config.OnSkip = func(s model.SkippedFile) {
+			s.File = nameOf(s.File)
+			onSkip(s)
+		}
+		err := scanner.Scan(ctx, config, filesChan)
 		if err != nil {
 			errChan <- err
+			cancel()
 		}
 	}()
 
+	ps := parser.Parser{Keys: opts.Attributes}
+	var parsed *cache.Cache
+	if opts.Cache && !virtual {
+		parsed = cache.Load(rootPath, cacheVersion(ps))
+	}
+
+	now := clock.OrSystem(opts.Clock).Now()
+	defaultLocation := opts.Location
+	if defaultLocation == nil {
+		defaultLocation = time.UTC
+	}
+
 	var wg sync.WaitGroup
-	numWorkers := runtime.NumCPU() * 2
+	numWorkers := opts.Workers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU() * 2
+	}
 
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for file := range filesChan {
-				fileHandle, err := os.Open(file)
-				if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				var result parser.Result
+				var skip *model.SkippedFile
+				if parsed != nil {
+					result, skip = scanCached(ps, parsed, fsys, file, nameOf(file))
+				} else {
+					result, skip = scanFile(ps, fsys, file, nameOf(file))
+				}
+				if skip != nil {
+					onSkip(*skip)
+				}
+				if len(result.Bombs) == 0 && len(result.Diagnostics) == 0 {
 					continue
 				}
 
-				bombs, err := parser.Parse(file, fileHandle)
-				fileHandle.Close()
+				// The cache holds on to the parse result, so it is copied
+				// before it is filled in
+				result.Bombs = append([]model.DebtBomb(nil), result.Bombs...)
+				result.Diagnostics = append([]model.Diagnostic(nil), result.Diagnostics...)
+				if opts.AddedLinesOnly && changes != nil {
+					result.Bombs, result.Diagnostics = onAddedLines(changes, result.Bombs, result.Diagnostics)
+				}
+				if blame {
+					blameFile(ctx, rootPath, opts.Rev, filepath.Join(rootPath, filepath.FromSlash(file)), result.Bombs)
+				}
+				resolved := result.Bombs[:0]
+				for _, b := range result.Bombs {
+					if !resolveExpiry(&b, now, opts.RelativeBase, defaultLocation) {
+						result.Diagnostics = append(result.Diagnostics, unresolvedExpiry(b))
+						continue
+					}
+					resolved = append(resolved, b)
+				}
+				result.Bombs = resolved
 
-				if err == nil && len(bombs) > 0 {
-					resultsChan <- bombs
+				select {
+				case resultsChan <- result:
+				case <-ctx.Done():
+					return
 				}
 			}
 		}()
@@ -54,26 +339,357 @@ func Run(rootPath string) ([]model.DebtBomb, error) {
 		wg.Wait()
 		close(resultsChan)
 	}()
-	var allBombs []model.DebtBomb
-	for bombs := range resultsChan {
-		allBombs = append(allBombs, bombs...)
+	var diagnostics []model.Diagnostic
+	var foundErr error
+	for result := range resultsChan {
+		if foundErr != nil {
+			continue
+		}
+		diagnostics = append(diagnostics, result.Diagnostics...)
+		if len(result.Bombs) > 0 {
+			if foundErr = found(result.Bombs); foundErr != nil {
+				cancel()
+			}
+		}
 	}
 
 	select {
 	case err := <-errChan:
-		return nil, err
+		return nil, nil, err
 	default:
 	}
+	if err := parent.Err(); err != nil {
+		return nil, nil, err
+	}
+	if foundErr != nil {
+		return nil, nil, foundErr
+	}
+
+	if parsed != nil {
+		// Best effort: a cache that cannot be written only costs speed.
+		// Only a scan of every file knows which entries are stale.
+		_ = parsed.Save(opts.ChangedSince == "" && opts.Paths == nil)
+	}
 
-	today := time.Now().Truncate(24 * time.Hour)
-	for i := range allBombs {
-		if today.After(allBombs[i].Expire) {
-			allBombs[i].IsExpired = true
+	sortDiagnostics(diagnostics)
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].File < skipped[j].File
+	})
+	return diagnostics, skipped, nil
+}
+
+// blameFile fills in the Git field of bombs, which all come from the file
+// at path
+func blameFile(ctx context.Context, root, rev, path string, bombs []model.DebtBomb) {
+	var lines []int
+	for _, b := range bombs {
+		// Lines in a notebook cell are not lines of the file
+		if b.Cell == 0 {
+			lines = append(lines, b.Line)
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	// Best effort: untracked files cannot be blamed
+	info, err := gitinfo.Blame(ctx, root, rev, path, lines)
+	if err != nil {
+		return
+	}
+	for i := range bombs {
+		if gi, ok := info[bombs[i].Line]; ok && bombs[i].Cell == 0 {
+			bombs[i].Git = &gi
 		}
 	}
-	sort.Slice(allBombs, func(i, j int) bool {
-		return allBombs[i].Expire.Before(allBombs[j].Expire)
+}
+
+// resolveExpiry sets the expire time of b in its time zone and whether it
+// is expired at now. It reports false for a relative expiry without a date
+// to count from.
+func resolveExpiry(b *model.DebtBomb, now, relativeBase time.Time, defaultLocation *time.Location) bool {
+	loc := defaultLocation
+	if b.TimeZone != "" {
+		if l, err := time.LoadLocation(b.TimeZone); err == nil {
+			loc = l
+		}
+	}
+
+	// Relative expiries count from the day of the commit that introduced
+	// the bomb, or from the given base when that is unknown
+	if parser.IsRelativeExpire(b.ExpireExpr) {
+		base := relativeBase
+		if b.Git != nil {
+			base = b.Git.Date
+		}
+		if base.IsZero() {
+			return false
+		}
+		b.Expire, _ = parser.ResolveExpire(b.ExpireExpr, base.In(loc))
+	}
+	if !b.HasExpireTime() {
+		y, m, d := b.Expire.Date()
+		b.Expire = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	b.IsExpired = b.ExpiredAt(now)
+	return true
+}
+
+// unresolvedExpiry reports a bomb whose relative expiry has nothing to
+// count from: git history is off or unavailable, or the line is not
+// committed yet
+func unresolvedExpiry(b model.DebtBomb) model.Diagnostic {
+	return model.Diagnostic{
+		File:    b.File,
+		Line:    b.Line,
+		Column:  b.Column,
+		Cell:    b.Cell,
+		Problem: fmt.Sprintf("relative expiry %s needs git history; commit the line or pass --relative-to", b.ExpireExpr),
+		RawText: b.RawText,
+	}
+}
+
+func sortDiagnostics(diagnostics []model.Diagnostic) {
+	sort.Slice(diagnostics, func(i, j int) bool {
+		if diagnostics[i].File != diagnostics[j].File {
+			return diagnostics[i].File < diagnostics[j].File
+		}
+		return diagnostics[i].Line < diagnostics[j].Line
 	})
+}
+
+// openFS returns the file system that replaces the work tree, or nil when
+// the work tree is scanned
+func openFS(ctx context.Context, opts Options) (fs.FS, error) {
+	// Files above the limit are skipped by size, so their content is not
+	// worth reading
+	maxSize := opts.MaxFileSize
+	if maxSize == 0 {
+		maxSize = scanner.DefaultMaxFileSize
+	}
+
+	switch {
+	case opts.FS != nil:
+		return opts.FS, nil
+	case opts.Rev != "":
+		tree, err := revisionFS(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("--rev %s: %w", opts.Rev, err)
+		}
+		return tree, nil
+	case opts.Archive != "":
+		return vfs.OpenArchive(opts.Archive, maxSize)
+	}
+	return nil, nil
+}
+
+// revisionFS returns the files of opts.Rev, reading from git only the
+// content the scan will use. The scan is run over the listing first to find
+// those files; the ignore files it consults on the way are read for it.
+func revisionFS(ctx context.Context, opts Options) (fs.FS, error) {
+	tree, err := gitinfo.ListTree(ctx, opts.RootPath, opts.Rev)
+	if err != nil {
+		return nil, err
+	}
+	isIgnoreFile := func(name string) bool {
+		return path.Base(name) == scanner.IgnoreFileName
+	}
+	listing, err := tree.FS(ctx, isIgnoreFile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := scannerConfig(opts)
+	config.FS = listing
+	paths := make(chan string, 100)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- scanner.Scan(ctx, config, paths)
+	}()
+	scanned := make(map[string]bool)
+	for p := range paths {
+		scanned[p] = true
+	}
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+
+	return tree.FS(ctx, func(name string) bool {
+		return scanned[name] || isIgnoreFile(name)
+	})
+}
+
+// scannerConfig returns the exclusions of opts. The file system, file
+// list and skip handler are left for the caller to fill in.
+func scannerConfig(opts Options) scanner.Config {
+	excluded := opts.Excluded
+	if excluded == nil {
+		excluded = scanner.DefaultExcluded()
+	}
+	return scanner.Config{
+		RootPath: opts.RootPath,
+		Excluded: excluded,
+		Paths:    opts.Paths,
+
+		IgnoredExtensions: opts.IgnoredExtensions,
+		MaxFileSize:       opts.MaxFileSize,
+	}
+}
 
-	return allBombs, nil
-}
\ No newline at end of file
+// cacheVersion identifies the parse results of ps, which depend on the
+// declared attribute keys as well as the parser
+func cacheVersion(ps parser.Parser) string {
+	if len(ps.Keys) == 0 {
+		return parser.Version
+	}
+	keys := append([]string(nil), ps.Keys...)
+	sort.Strings(keys)
+	return parser.Version + "+" + strings.Join(keys, ",")
+}
+
+// scanFile parses the file at path in fsys, reporting it as name. Bombs
+// found before a line that is too long are kept; the file is still
+// reported as skipped.
+func scanFile(ps parser.Parser, fsys fs.FS, path, name string) (parser.Result, *model.SkippedFile) {
+	fileHandle, err := fsys.Open(path)
+	if err != nil {
+		return parser.Result{}, &model.SkippedFile{File: name, Reason: model.SkipUnreadable, Detail: err.Error()}
+	}
+	defer fileHandle.Close()
+	return parse(ps, name, fileHandle)
+}
+
+// scanCached returns the cached result for the file when its size and
+// mtime, or else its content, did not change, and parses it otherwise
+func scanCached(ps parser.Parser, c *cache.Cache, fsys fs.FS, path, name string) (parser.Result, *model.SkippedFile) {
+	info, err := fs.Stat(fsys, path)
+	if err != nil {
+		return scanFile(ps, fsys, path, name)
+	}
+	size, modTime := info.Size(), info.ModTime().UnixNano()
+	if e, ok := c.Get(name, size, modTime); ok {
+		return parser.Result{Bombs: e.Bombs, Diagnostics: e.Diagnostics}, e.Skipped
+	}
+
+	// The scanner already skipped files above the size limit
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return parser.Result{}, &model.SkippedFile{File: name, Reason: model.SkipUnreadable, Detail: err.Error()}
+	}
+	hash := cache.Hash(data)
+	if e, ok := c.GetByHash(name, hash); ok {
+		e.Size, e.ModTime = size, modTime
+		c.Put(name, e)
+		return parser.Result{Bombs: e.Bombs, Diagnostics: e.Diagnostics}, e.Skipped
+	}
+
+	result, skip := parse(ps, name, bytes.NewReader(data))
+	c.Put(name, cache.Entry{
+		Size:        size,
+		ModTime:     modTime,
+		Hash:        hash,
+		Bombs:       result.Bombs,
+		Diagnostics: result.Diagnostics,
+		Skipped:     skip,
+	})
+	return result, skip
+}
+
+// parse sniffs for binary content and parses the rest, through a format
+// adapter for notebooks and markup
+func parse(ps parser.Parser, file string, r io.Reader) (parser.Result, *model.SkippedFile) {
+	reader := bufio.NewReaderSize(r, sniffSize)
+	head, err := reader.Peek(sniffSize)
+	if err != nil && err != io.EOF {
+		return parser.Result{}, &model.SkippedFile{File: file, Reason: model.SkipUnreadable, Detail: err.Error()}
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return parser.Result{}, &model.SkippedFile{File: file, Reason: model.SkipBinary}
+	}
+
+	var result parser.Result
+	if adapt := format.For(file); adapt != nil {
+		// Adapters need the whole file, e.g. to decode a notebook
+		data, readErr := io.ReadAll(reader)
+		if readErr != nil {
+			return parser.Result{}, &model.SkippedFile{File: file, Reason: model.SkipUnreadable, Detail: readErr.Error()}
+		}
+		sections, adaptErr := adapt(data)
+		if adaptErr != nil {
+			return parser.Result{}, &model.SkippedFile{File: file, Reason: model.SkipMalformed, Detail: adaptErr.Error()}
+		}
+		result, err = ps.ParseSections(file, sections)
+	} else {
+		result, err = ps.ParseFile(file, reader)
+	}
+	if err == bufio.ErrTooLong {
+		return result, &model.SkippedFile{File: file, Reason: model.SkipLineTooLong, Detail: "stopped reading after the last complete line"}
+	}
+	if err != nil {
+		return parser.Result{}, &model.SkippedFile{File: file, Reason: model.SkipUnreadable, Detail: err.Error()}
+	}
+	return result, nil
+}
+
+// withinPaths keeps the files that are, or lie below, one of paths
+func withinPaths(files, paths []string) []string {
+	kept := []string{}
+	for _, file := range files {
+		for _, p := range paths {
+			if scanner.Within(filepath.Clean(file), filepath.Clean(p)) {
+				kept = append(kept, file)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// onAddedLines keeps the bombs and diagnostics whose marker line was added
+// or modified. Cell lines do not map to diff lines, so everything in a
+// changed notebook is kept.
+func onAddedLines(changes map[string]gitinfo.Change, bombs []model.DebtBomb, diagnostics []model.Diagnostic) ([]model.DebtBomb, []model.Diagnostic) {
+	var keptBombs []model.DebtBomb
+	for _, b := range bombs {
+		if b.Cell > 0 || changes[b.File].HasAddedLine(b.Line) {
+			keptBombs = append(keptBombs, b)
+		}
+	}
+	var keptDiagnostics []model.Diagnostic
+	for _, d := range diagnostics {
+		if d.Cell > 0 || changes[d.File].HasAddedLine(d.Line) {
+			keptDiagnostics = append(keptDiagnostics, d)
+		}
+	}
+	return keptBombs, keptDiagnostics
+}
+
+// duplicateIDs reports bombs that share an ID with an earlier one. IDs key
+// the Jira state, so duplicates would share a ticket. Generated IDs are
+// distinct, so only explicit id= attributes can clash.
+func duplicateIDs(bombs []model.DebtBomb) []model.Diagnostic {
+	var diagnostics []model.Diagnostic
+	seen := make(map[string]model.DebtBomb)
+	for _, b := range bombs {
+		if d, ok := duplicateID(seen, b); ok {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics
+}
+
+// duplicateID reports b if a bomb in seen has its ID, and adds it otherwise
+func duplicateID(seen map[string]model.DebtBomb, b model.DebtBomb) (model.Diagnostic, bool) {
+	first, ok := seen[b.ID]
+	if !ok {
+		seen[b.ID] = b
+		return model.Diagnostic{}, false
+	}
+	return model.Diagnostic{
+		File:    b.File,
+		Line:    b.Line,
+		Column:  b.Column,
+		Cell:    b.Cell,
+		Problem: fmt.Sprintf("duplicate id %q (also at %s); give each bomb its own id= attribute", b.ID, first.Location()),
+		RawText: b.RawText,
+	}, true
+}
@@ -0,0 +1,442 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jobin-404/debtbomb/internal/clock"
+	"github.com/jobin-404/debtbomb/internal/model"
+	"github.com/jobin-404/debtbomb/internal/vfs"
+)
+
+func TestRunReportsSkippedFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"ok.go":      "// @debtbomb(expire=2020-01-01)\nfunc a() {}\n",
+		"binary.dat": "abc\x00def",
+		"big.txt":    strings.Repeat("x", 1024*1024+1),
+		"long.py":    "# @debtbomb(expire=2020-01-01)\nx = 1\ny = '" + strings.Repeat("a", 70000) + "'\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Run(context.Background(), Options{RootPath: root})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	reasons := make(map[string]string)
+	for _, s := range result.Skipped {
+		reasons[filepath.Base(s.File)] = s.Reason
+	}
+	expected := map[string]string{
+		"binary.dat": model.SkipBinary,
+		"big.txt":    model.SkipTooLarge,
+		"long.py":    model.SkipLineTooLong,
+	}
+	if len(reasons) != len(expected) {
+		t.Fatalf("Expected %d skipped files, got %+v", len(expected), result.Skipped)
+	}
+	for name, reason := range expected {
+		if reasons[name] != reason {
+			t.Errorf("Expected %s to be skipped as %q, got %q", name, reason, reasons[name])
+		}
+	}
+
+	// Bombs before the long line are still found
+	if len(result.Bombs) != 2 {
+		t.Errorf("Expected 2 bombs, got %d", len(result.Bombs))
+	}
+}
+
+func TestRunStopsOnWalkError(t *testing.T) {
+	_, err := Run(context.Background(), Options{RootPath: filepath.Join(t.TempDir(), "missing")})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+
+	_, err := Run(ctx, Options{RootPath: t.TempDir()})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a deadline error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout message, got %q", err.Error())
+	}
+}
+
+func TestRunExpiresAtStartOfNextDayInTimeZone(t *testing.T) {
+	root := t.TempDir()
+	src := "// @debtbomb(expire=2026-01-31)\nfunc a() {}\n" +
+		"// @debtbomb(expire=2026-01-31, tz=Asia/Tokyo)\nfunc b() {}\n" +
+		"// @debtbomb(expire=2026-01-31T20:00:00-08:00)\nfunc c() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	tests := []struct {
+		now  string
+		want map[int]bool // line -> expired
+	}{
+		// 2026-01-31 23:00 in Los Angeles, 2026-02-01 16:00 in Tokyo
+		{"2026-02-01T07:00:00Z", map[int]bool{1: false, 3: true, 5: true}},
+		// 2026-01-31 19:00 in Los Angeles
+		{"2026-02-01T03:00:00Z", map[int]bool{1: false, 3: true, 5: false}},
+		// Midnight in Los Angeles
+		{"2026-02-01T08:00:00Z", map[int]bool{1: true, 3: true, 5: true}},
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse(time.RFC3339, tt.now)
+		result, err := Run(context.Background(), Options{RootPath: root, Clock: clock.Fixed(now), Location: la})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		for _, b := range result.Bombs {
+			if b.IsExpired != tt.want[b.Line] {
+				t.Errorf("At %s, line %d: expected expired=%v", tt.now, b.Line, tt.want[b.Line])
+			}
+		}
+	}
+}
+
+func TestRunRelativeExpiryWithoutHistory(t *testing.T) {
+	root := t.TempDir()
+	content := "// @debtbomb(expire=+30d)\nfunc a() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	today := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Without git there is no commit date to count from
+	result, err := Run(context.Background(), Options{RootPath: root, Clock: clock.Fixed(today)})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(result.Bombs) != 0 || len(result.Diagnostics) != 1 {
+		t.Fatalf("Expected a diagnostic instead of a bomb, got %+v and %+v", result.Bombs, result.Diagnostics)
+	}
+	if !strings.Contains(result.Diagnostics[0].Problem, "needs git history") {
+		t.Errorf("Unexpected problem %q", result.Diagnostics[0].Problem)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	result, err = Run(context.Background(), Options{RootPath: root, Clock: clock.Fixed(today), RelativeBase: base})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(result.Bombs) != 1 || len(result.Diagnostics) != 0 {
+		t.Fatalf("Expected one bomb, got %+v and %+v", result.Bombs, result.Diagnostics)
+	}
+	if b := result.Bombs[0]; !b.Expire.Equal(base.AddDate(0, 0, 30)) || !b.IsExpired {
+		t.Errorf("Expected the bomb to expire 30 days after the base, got %v", b.Expire)
+	}
+}
+
+func TestRunCache(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "// @debtbomb(expire=2020-01-01, reason=first)\nfunc a() {}\n")
+	write("b.go", "func b() {}\n")
+
+	opts := Options{RootPath: root, Cache: true}
+	if _, err := Run(context.Background(), opts); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".debtbomb", "cache", ".gitignore")); err != nil {
+		t.Fatalf("Expected the cache to be written: %v", err)
+	}
+
+	// A changed file is parsed again; the cache itself is never scanned
+	write("b.go", "// @debtbomb(expire=2020-01-01, reason=second)\nfunc b() {}\n")
+	result, err := Run(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	var reasons []string
+	for _, b := range result.Bombs {
+		reasons = append(reasons, b.Reason)
+	}
+	if strings.Join(reasons, ",") != "first,second" {
+		t.Errorf("Expected bombs first and second, got %v", reasons)
+	}
+	if !result.Bombs[0].IsExpired {
+		t.Errorf("Expected cached bomb to be expired")
+	}
+}
+
+// BenchmarkRun scans a synthetic tree of 20,000 files, one in ten with a
+// bomb, with and without a warm cache
+func BenchmarkRun(b *testing.B) {
+	root := b.TempDir()
+	for i := 0; i < 20000; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("pkg%03d", i/100))
+		if i%100 == 0 {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				b.Fatal(err)
+			}
+		}
+		content := strings.Repeat("func f() {\n\treturn\n}\n", 50)
+		if i%10 == 0 {
+			content = "// @debtbomb(expire=2030-01-01, owner=team)\n" + content
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.go", i)), []byte(content), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for _, bc := range []struct {
+		name  string
+		cache bool
+	}{
+		{"NoCache", false},
+		{"WarmCache", true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			opts := Options{RootPath: root, Cache: bc.cache, Excluded: []string{}}
+			if _, err := Run(context.Background(), opts); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				result, err := Run(context.Background(), opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(result.Bombs) != 2000 {
+					b.Fatalf("Expected 2000 bombs, got %d", len(result.Bombs))
+				}
+			}
+		})
+	}
+}
+
+func TestRunPathsAndBase(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "project")
+	for _, dir := range []string{"src", "lib", "docs"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := "// @debtbomb(expire=2020-01-01)\nfunc " + dir + "() {}\n"
+		if err := os.WriteFile(filepath.Join(root, dir, "a.go"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// src/a.go lies inside src and is only scanned once
+	result, err := Run(context.Background(), Options{
+		RootPath: root,
+		Paths:    []string{"src/a.go", "lib", "src", "missing.go"},
+		Base:     filepath.Dir(root),
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var files []string
+	for _, b := range result.Bombs {
+		files = append(files, filepath.ToSlash(b.File))
+	}
+	if strings.Join(files, ",") != "project/lib/a.go,project/src/a.go" {
+		t.Errorf("Expected the bombs in lib and src relative to the base, got %v", files)
+	}
+	if len(result.Diagnostics) != 0 {
+		t.Errorf("Expected no duplicate diagnostics, got %+v", result.Diagnostics)
+	}
+}
+
+func TestRunFS(t *testing.T) {
+	root := t.TempDir()
+	content := "// @debtbomb(expire=2020-01-01)\nfunc a() {}\n"
+	if err := os.MkdirAll(filepath.Join(root, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "src", "a.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	onDisk, err := Run(context.Background(), Options{RootPath: root, Base: root})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// The work tree is empty apart from src/a.go; the scan only sees FS
+	fsys := fstest.MapFS{
+		"src/a.go":              {Data: []byte(content)},
+		"node_modules/x/b.go":   {Data: []byte(content)},
+		"docs/.debtbombignore":  {Data: []byte("*.md\n")},
+		"docs/notes.md":         {Data: []byte("<!-- @debtbomb(expire=2020-01-01) -->\n")},
+		"docs/guide/install.py": {Data: []byte("# @debtbomb(expire=2020-01-01)\nx = 1\n")},
+	}
+	result, err := Run(context.Background(), Options{RootPath: root, Base: root, FS: fsys})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var files []string
+	for _, b := range result.Bombs {
+		files = append(files, filepath.ToSlash(b.File))
+	}
+	if strings.Join(files, ",") != "docs/guide/install.py,src/a.go" {
+		t.Fatalf("Expected the bombs in docs/guide and src, got %v", files)
+	}
+	if result.Bombs[1].ID != onDisk.Bombs[0].ID {
+		t.Errorf("Expected the same ID as on disk, got %q and %q", result.Bombs[1].ID, onDisk.Bombs[0].ID)
+	}
+
+	if _, err := Run(context.Background(), Options{RootPath: root, FS: fsys, ChangedSince: "main"}); err == nil {
+		t.Error("Expected --changed-since to fail outside the work tree")
+	}
+}
+
+func TestRevisionFSReadsOnlyScannedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	content := "// @debtbomb(expire=2020-01-01)\nfunc a() {}\n"
+	for name, data := range map[string]string{
+		"src/a.go":             content,
+		"node_modules/x/b.go":  content,
+		"docs/.debtbombignore": "*.md\n",
+		"docs/notes.md":        "<!-- @debtbomb(expire=2020-01-01) -->\n",
+		"logo.png":             "\x89PNG",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=Ada", "-c", "user.email=ada@example.com", "-c", "commit.gpgsign=false", "commit", "-q", "-m", "first"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	fsys, err := revisionFS(context.Background(), Options{RootPath: root, Rev: "HEAD"})
+	if err != nil {
+		t.Fatalf("revisionFS failed: %v", err)
+	}
+	for _, name := range []string{"src/a.go", "docs/.debtbombignore"} {
+		if _, err := fs.ReadFile(fsys, name); err != nil {
+			t.Errorf("Expected %s to be read, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"node_modules/x/b.go", "docs/notes.md", "logo.png"} {
+		if _, err := fsys.Open(name); !errors.Is(err, vfs.ErrNotLoaded) {
+			t.Errorf("Expected excluded %s not to be read, got %v", name, err)
+		}
+	}
+
+	result, err := Run(context.Background(), Options{RootPath: root, Base: root, Rev: "HEAD"})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(result.Bombs) != 1 || filepath.ToSlash(result.Bombs[0].File) != "src/a.go" || len(result.Skipped) != 0 {
+		t.Errorf("Expected only the bomb in src/a.go, got %+v, skipped %+v", result.Bombs, result.Skipped)
+	}
+}
+
+func TestStream(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"a.go":       "// @debtbomb(expire=2020-01-01, id=same)\nfunc a() {}\n",
+		"b.go":       "// @debtbomb(expire=2099-01-01, id=same)\nfunc b() {}\n",
+		"bad.go":     "// @debtbomb(owner=x)\nfunc c() {}\n",
+		"binary.dat": "abc\x00def",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bombs := make(chan model.DebtBomb)
+	var summary *Summary
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		summary, err = Stream(context.Background(), Options{RootPath: root, Base: root}, bombs)
+	}()
+	var files []string
+	for b := range bombs {
+		files = append(files, b.File)
+	}
+	<-done
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	sort.Strings(files)
+	if strings.Join(files, ",") != "a.go,b.go" {
+		t.Errorf("Expected the bombs in a.go and b.go, got %v", files)
+	}
+	if summary.Bombs != 2 || summary.Expired != 1 {
+		t.Errorf("Expected 2 bombs with 1 expired, got %+v", summary)
+	}
+	// The malformed marker and the duplicate ID
+	if len(summary.Diagnostics) != 2 {
+		t.Errorf("Expected 2 diagnostics, got %+v", summary.Diagnostics)
+	}
+	if len(summary.Skipped) != 1 || summary.Skipped[0].Reason != model.SkipBinary {
+		t.Errorf("Expected binary.dat to be skipped, got %+v", summary.Skipped)
+	}
+}
+
+func TestStreamCanceled(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 50; i++ {
+		content := fmt.Sprintf("// @debtbomb(expire=2020-01-01)\nfunc f%d() {}\n", i)
+		if err := os.WriteFile(filepath.Join(root, fmt.Sprintf("f%d.go", i)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nobody reads after the first bomb; canceling must not leave Stream blocked
+	ctx, cancel := context.WithCancel(context.Background())
+	bombs := make(chan model.DebtBomb)
+	errs := make(chan error, 1)
+	go func() {
+		_, err := Stream(ctx, Options{RootPath: root}, bombs)
+		errs <- err
+	}()
+	<-bombs
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package engine
+
+import "path/filepath"
+
+// pathNames turns scanned paths into reported ones
+type pathNames struct {
+	// base is absolute; an empty base reports paths as scanned
+	base string
+}
+
+func newPathNames(base string) (pathNames, error) {
+	if base == "" {
+		return pathNames{}, nil
+	}
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return pathNames{}, err
+	}
+	return pathNames{base: absBase}, nil
+}
+
+// display returns the reported path of a scanned file
+func (n pathNames) display(path string) string {
+	if n.base == "" {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(n.base, abs)
+	if err != nil {
+		return path
+	}
+	return rel
+}
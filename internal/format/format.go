@@ -0,0 +1,70 @@
+package format
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Section is a part of a file that is lexed as one language. Sections of
+// the same file are masked copies of it: everything that belongs to
+// another section is blanked out, so lines and columns stay those of the
+// file. Notebook cells are the exception and count from the cell start.
+type Section struct {
+	// Lang is a file name whose extension picks the lexer, e.g. "cell.py"
+	Lang string
+	Text string
+	// Cell is the 1-based notebook cell the section comes from, or 0
+	Cell int
+}
+
+// Adapter splits the content of a file into sections
+type Adapter func(data []byte) ([]Section, error)
+
+var byExtension = map[string]Adapter{
+	".ipynb":    Notebook,
+	".md":       Markdown,
+	".markdown": Markdown,
+	".html":     HTML,
+	".htm":      HTML,
+}
+
+// For returns the adapter for a file, or nil when the file is lexed as a
+// whole by its extension
+func For(filename string) Adapter {
+	return byExtension[strings.ToLower(filepath.Ext(filename))]
+}
+
+// span is a byte range of a text
+type span struct {
+	start, end int
+}
+
+// keep returns text with every byte outside spans replaced by a space.
+// Line breaks are kept so that positions do not move.
+func keep(text string, spans []span) string {
+	out := []byte(text)
+	next := 0
+	for _, s := range spans {
+		blank(out[next:s.start])
+		next = s.end
+	}
+	blank(out[next:])
+	return string(out)
+}
+
+// drop returns text with every byte inside spans replaced by a space
+func drop(text string, spans []span) string {
+	out := []byte(text)
+	for _, s := range spans {
+		blank(out[s.start:s.end])
+	}
+	return string(out)
+}
+
+func blank(b []byte) {
+	for i, c := range b {
+		if c != '\n' && c != '\r' {
+			b[i] = ' '
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+// position returns the 1-based line and column of the first occurrence of
+// substr in text, or 0, 0 when it is not there
+func position(text, substr string) (int, int) {
+	idx := strings.Index(text, substr)
+	if idx == -1 {
+		return 0, 0
+	}
+	before := text[:idx]
+	return strings.Count(before, "\n") + 1, idx - strings.LastIndex(before, "\n")
+}
+
+// sectionFor returns the section lexed as lang
+func sectionFor(t *testing.T, sections []Section, lang string) Section {
+	t.Helper()
+	for _, s := range sections {
+		if s.Lang == lang {
+			return s
+		}
+	}
+	t.Fatalf("Expected a %s section, got %+v", lang, sections)
+	return Section{}
+}
+
+func TestFor(t *testing.T) {
+	for file, ok := range map[string]bool{
+		"docs/README.MD":   true,
+		"index.htm":        true,
+		"analysis.ipynb":   true,
+		"main.go":          false,
+		"notes.markdown.1": false,
+	} {
+		if got := For(file) != nil; got != ok {
+			t.Errorf("For(%q): expected an adapter %v, got %v", file, ok, got)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	src := strings.Join([]string{
+		"---",
+		"# front: matter",
+		"---",
+		"<!-- prose -->",
+		"```go",
+		"// <!-- fenced -->",
+		"```",
+		"~~~~",
+		"<!-- tilde -->",
+		"```",
+		"<!-- still fenced -->",
+		"~~~~",
+		"  <!-- after -->",
+		"",
+	}, "\n")
+
+	sections, err := Markdown([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("Expected the page and its front matter, got %+v", sections)
+	}
+
+	page := sectionFor(t, sections, "page.md")
+	if len(page.Text) != len(src) || strings.Count(page.Text, "\n") != strings.Count(src, "\n") {
+		t.Errorf("Expected the page to keep the size and lines of the file")
+	}
+	for _, want := range []struct {
+		text      string
+		line, col int
+	}{
+		{"<!-- prose -->", 4, 1},
+		{"<!-- after -->", 13, 3},
+	} {
+		if line, col := position(page.Text, want.text); line != want.line || col != want.col {
+			t.Errorf("Expected %q at %d:%d, got %d:%d", want.text, want.line, want.col, line, col)
+		}
+	}
+	// A ``` line inside a ~~~~ block does not close it
+	for _, hidden := range []string{"front: matter", "fenced", "tilde", "still fenced"} {
+		if strings.Contains(page.Text, hidden) {
+			t.Errorf("Expected %q to be blanked out of the page", hidden)
+		}
+	}
+
+	yaml := sectionFor(t, sections, "front-matter.yaml")
+	if line, col := position(yaml.Text, "# front: matter"); line != 2 || col != 1 {
+		t.Errorf("Expected the front matter comment at 2:1, got %d:%d", line, col)
+	}
+	if strings.Contains(yaml.Text, "---") || strings.Contains(yaml.Text, "prose") {
+		t.Errorf("Expected only the front matter in its section, got %q", yaml.Text)
+	}
+}
+
+func TestMarkdownUnclosedFrontMatter(t *testing.T) {
+	sections, err := Markdown([]byte("---\n<!-- prose -->\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 1 || !strings.Contains(sections[0].Text, "<!-- prose -->") {
+		t.Errorf("Expected a page without front matter, got %+v", sections)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	src := strings.Join([]string{
+		"<!-- markup -->",
+		"<SCRIPT>",
+		"  // script",
+		"</SCRIPT>",
+		`<script type="application/json">{"a": "// json"}</script>`,
+		`<script type="module">/* module */</script>`,
+		"<style media=print>",
+		"  /* style */",
+		"</style>",
+		"<scripted>// not a script</scripted>",
+		"",
+	}, "\n")
+
+	sections, err := HTML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 3 {
+		t.Fatalf("Expected page, script and style sections, got %+v", sections)
+	}
+
+	page := sectionFor(t, sections, "page.html")
+	if line, _ := position(page.Text, "<!-- markup -->"); line != 1 {
+		t.Errorf("Expected the markup comment on line 1, got %d", line)
+	}
+	if !strings.Contains(page.Text, "// not a script") {
+		t.Errorf("Expected <scripted> to stay markup")
+	}
+	for _, code := range []string{"// script", "// json", "/* module */", "/* style */"} {
+		if strings.Contains(page.Text, code) {
+			t.Errorf("Expected %q to be blanked out of the page", code)
+		}
+	}
+
+	script := sectionFor(t, sections, "script.js")
+	if line, col := position(script.Text, "// script"); line != 3 || col != 3 {
+		t.Errorf("Expected the script comment at 3:3, got %d:%d", line, col)
+	}
+	if line, _ := position(script.Text, "/* module */"); line != 6 {
+		t.Errorf("Expected the module comment on line 6, got %d", line)
+	}
+	// JSON has no comments
+	if strings.Contains(script.Text, "json") || strings.Contains(script.Text, "<!--") {
+		t.Errorf("Expected only JavaScript in the script section, got %q", script.Text)
+	}
+
+	style := sectionFor(t, sections, "style.css")
+	if line, col := position(style.Text, "/* style */"); line != 8 || col != 3 {
+		t.Errorf("Expected the style comment at 8:3, got %d:%d", line, col)
+	}
+	if strings.Contains(style.Text, "script") {
+		t.Errorf("Expected only CSS in the style section, got %q", style.Text)
+	}
+}
+
+func TestNotebook(t *testing.T) {
+	src := `{
+  "metadata": {"kernelspec": {"language": "R"}},
+  "cells": [
+    {"cell_type": "markdown", "source": ["# Title\n", "\n", "<!-- first -->\n"]},
+    {"cell_type": "code", "source": "x <- 1\n# code\n"},
+    {"cell_type": "raw", "source": "# raw\n"},
+    {"cell_type": "markdown", "source": "---\n# yaml\n---\n` + "```" + `\n<!-- fenced -->\n` + "```" + `\n<!-- second -->"}
+  ]
+}`
+
+	sections, err := Notebook([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, s := range sections {
+		got = append(got, s.Lang)
+	}
+	if strings.Join(got, ",") != "page.md,cell.r,page.md,front-matter.yaml" {
+		t.Fatalf("Expected sections for the markdown, code and markdown cells, got %v", got)
+	}
+
+	for _, want := range []struct {
+		section    int
+		text       string
+		cell, line int
+	}{
+		{0, "<!-- first -->", 1, 3},
+		// A string source is read like a list of lines
+		{1, "# code", 2, 2},
+		// Lines count from the start of the cell, not of the file
+		{2, "<!-- second -->", 4, 7},
+		{3, "# yaml", 4, 2},
+	} {
+		s := sections[want.section]
+		line, _ := position(s.Text, want.text)
+		if s.Cell != want.cell || line != want.line {
+			t.Errorf("Expected %q in cell %d on line %d, got cell %d line %d", want.text, want.cell, want.line, s.Cell, line)
+		}
+	}
+	if strings.Contains(sections[2].Text, "fenced") {
+		t.Errorf("Expected fenced blocks in markdown cells to be left out")
+	}
+}
+
+func TestNotebookKernelLanguage(t *testing.T) {
+	for metadata, want := range map[string]string{
+		`{}`: "cell.py",
+		`{"language_info": {"name": "python", "file_extension": ".py"}, "kernelspec": {"language": "go"}}`: "cell.py",
+		`{"language_info": {"name": "Julia"}}`:  "cell.jl",
+		`{"kernelspec": {"language": "cobol"}}`: "cell.py",
+	} {
+		src := `{"metadata": ` + metadata + `, "cells": [{"cell_type": "code", "source": []}]}`
+		sections, err := Notebook([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sections) != 1 || sections[0].Lang != want {
+			t.Errorf("Metadata %s: expected %s, got %+v", metadata, want, sections)
+		}
+	}
+}
+
+func TestNotebookInvalid(t *testing.T) {
+	for _, src := range []string{
+		`not json`,
+		`{"cells": [{"cell_type": "code", "source": 42}]}`,
+	} {
+		if _, err := Notebook([]byte(src)); err == nil || !strings.Contains(err.Error(), "invalid notebook") {
+			t.Errorf("Expected an invalid notebook error for %s, got %v", src, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package format
+
+import "strings"
+
+// HTML finds <!-- --> comments in the markup and comments in the code of
+// <script> and <style> elements, where markup comments do not apply
+func HTML(data []byte) ([]Section, error) {
+	text := string(data)
+	lower := strings.ToLower(text)
+	var scripts, styles, other []span
+
+	i := 0
+	for i < len(lower) {
+		next := strings.IndexByte(lower[i:], '<')
+		if next == -1 {
+			break
+		}
+		i += next
+		if strings.HasPrefix(lower[i:], "<!--") {
+			end := strings.Index(lower[i+4:], "-->")
+			if end == -1 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+
+		name := ""
+		for _, n := range []string{"script", "style"} {
+			if isTag(lower[i+1:], n) {
+				name = n
+			}
+		}
+		if name == "" {
+			i++
+			continue
+		}
+
+		open := strings.IndexByte(lower[i:], '>')
+		if open == -1 {
+			break
+		}
+		tag := lower[i : i+open]
+		start := i + open + 1
+		end := strings.Index(lower[start:], "</"+name)
+		if end == -1 {
+			end = len(lower)
+		} else {
+			end += start
+		}
+
+		switch {
+		case name == "style":
+			styles = append(styles, span{start, end})
+		case isJavaScript(tag):
+			scripts = append(scripts, span{start, end})
+		default:
+			// JSON, templates and the like have no comments of their own
+			other = append(other, span{start, end})
+		}
+		i = end
+	}
+
+	sections := []Section{{Lang: "page.html", Text: drop(text, append(append(append([]span{}, scripts...), styles...), other...))}}
+	if len(scripts) > 0 {
+		sections = append(sections, Section{Lang: "script.js", Text: keep(text, scripts)})
+	}
+	if len(styles) > 0 {
+		sections = append(sections, Section{Lang: "style.css", Text: keep(text, styles)})
+	}
+	return sections, nil
+}
+
+// isTag reports whether s starts with the name of a tag followed by the
+// end of the name
+func isTag(s, name string) bool {
+	if !strings.HasPrefix(s, name) || len(s) == len(name) {
+		return false
+	}
+	switch s[len(name)] {
+	case ' ', '\t', '\n', '\r', '>', '/':
+		return true
+	}
+	return false
+}
+
+// isJavaScript reports whether a lowercase <script> tag holds JavaScript
+func isJavaScript(tag string) bool {
+	idx := strings.Index(tag, "type=")
+	if idx == -1 {
+		return true
+	}
+	fields := strings.Fields(tag[idx+len("type="):])
+	if len(fields) == 0 {
+		return true
+	}
+	typ := strings.Trim(fields[0], `"'/`)
+	return typ == "module" || strings.Contains(typ, "javascript") || strings.Contains(typ, "ecmascript")
+}
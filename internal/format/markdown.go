@@ -0,0 +1,98 @@
+package format
+
+import "strings"
+
+// Markdown finds <!-- --> comments in the prose of a markdown file and
+// comments in YAML front matter. Fenced code blocks are left out: they are
+// usually examples, and markup inside them is not a comment.
+func Markdown(data []byte) ([]Section, error) {
+	text := string(data)
+	var frontMatter []span
+	var fences []span
+
+	lines := splitLines(text)
+	i := 0
+	if len(lines) > 0 && strings.TrimRight(lines[0].text, " \t\r") == "---" {
+		for j := 1; j < len(lines); j++ {
+			if l := strings.TrimRight(lines[j].text, " \t\r"); l == "---" || l == "..." {
+				frontMatter = append(frontMatter, span{lines[1].start, lines[j].start})
+				i = j + 1
+				break
+			}
+		}
+	}
+
+	for ; i < len(lines); i++ {
+		marker, ok := openingFence(lines[i].text)
+		if !ok {
+			continue
+		}
+		start := lines[i].end
+		end := len(text)
+		j := i + 1
+		for ; j < len(lines); j++ {
+			if closesFence(lines[j].text, marker) {
+				end = lines[j].start
+				break
+			}
+		}
+		if start < end {
+			fences = append(fences, span{start, end})
+		}
+		i = j
+	}
+
+	sections := []Section{{Lang: "page.md", Text: drop(text, append(frontMatter, fences...))}}
+	if len(frontMatter) > 0 {
+		sections = append(sections, Section{Lang: "front-matter.yaml", Text: keep(text, frontMatter)})
+	}
+	return sections, nil
+}
+
+// line is one line of a text; end includes the line break
+type line struct {
+	text       string
+	start, end int
+}
+
+func splitLines(text string) []line {
+	var lines []line
+	start := 0
+	for start < len(text) {
+		end := strings.IndexByte(text[start:], '\n')
+		if end == -1 {
+			lines = append(lines, line{text[start:], start, len(text)})
+			break
+		}
+		end += start + 1
+		lines = append(lines, line{text[start : end-1], start, end})
+		start = end
+	}
+	return lines
+}
+
+// openingFence returns the ``` or ~~~ run that opens a fenced code block
+func openingFence(text string) (string, bool) {
+	trimmed := strings.TrimLeft(text, " ")
+	if len(text)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return "", false
+	}
+	c := trimmed[0]
+	if c != '`' && c != '~' {
+		return "", false
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == c {
+		n++
+	}
+	if n < 3 || (c == '`' && strings.ContainsRune(trimmed[n:], '`')) {
+		return "", false
+	}
+	return trimmed[:n], true
+}
+
+// closesFence reports whether text closes a block opened with marker
+func closesFence(text, marker string) bool {
+	trimmed := strings.TrimSpace(text)
+	return len(trimmed) >= len(marker) && strings.Trim(trimmed, marker[:1]) == ""
+}
@@ -0,0 +1,106 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		LanguageInfo struct {
+			Name          string `json:"name"`
+			FileExtension string `json:"file_extension"`
+		} `json:"language_info"`
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType string `json:"cell_type"`
+	// Source is a string or a list of lines that keep their line breaks
+	Source json.RawMessage `json:"source"`
+}
+
+// kernelExtensions maps notebook languages to a file extension that the
+// lexer knows
+var kernelExtensions = map[string]string{
+	"python":     ".py",
+	"r":          ".r",
+	"julia":      ".jl",
+	"scala":      ".scala",
+	"javascript": ".js",
+	"typescript": ".ts",
+	"bash":       ".sh",
+	"sql":        ".sql",
+	"c++":        ".cpp",
+	"java":       ".java",
+	"go":         ".go",
+	"rust":       ".rs",
+}
+
+// Notebook returns a section per code and markdown cell of a Jupyter
+// notebook. Code cells are lexed in the kernel's language, Python unless
+// the metadata says otherwise; markdown cells as Markdown.
+func Notebook(data []byte) ([]Section, error) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return nil, fmt.Errorf("invalid notebook: %w", err)
+	}
+
+	lang := "cell" + notebookExtension(nb)
+	var sections []Section
+	for i, cell := range nb.Cells {
+		source, err := cellSource(cell.Source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notebook: cell %d: %w", i+1, err)
+		}
+
+		switch cell.CellType {
+		case "code":
+			sections = append(sections, Section{Lang: lang, Text: source, Cell: i + 1})
+		case "markdown":
+			parts, err := Markdown([]byte(source))
+			if err != nil {
+				return nil, err
+			}
+			for _, part := range parts {
+				part.Cell = i + 1
+				sections = append(sections, part)
+			}
+		}
+	}
+	return sections, nil
+}
+
+func notebookExtension(nb notebook) string {
+	if ext := nb.Metadata.LanguageInfo.FileExtension; strings.HasPrefix(ext, ".") {
+		return ext
+	}
+	name := nb.Metadata.LanguageInfo.Name
+	if name == "" {
+		name = nb.Metadata.KernelSpec.Language
+	}
+	if ext, ok := kernelExtensions[strings.ToLower(name)]; ok {
+		return ext
+	}
+	return ".py"
+}
+
+func cellSource(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	var lines []string
+	if err := json.Unmarshal(raw, &lines); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, ""), nil
+}
@@ -0,0 +1,303 @@
+package gitinfo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+// ErrNotRepository is returned when the root is not inside a git work tree
+// or the git binary is not available
+var ErrNotRepository = errors.New("not a git repository")
+
+// uncommitted is the hash git blame reports for lines that are not committed yet
+const uncommitted = "0000000000000000000000000000000000000000"
+
+// run executes git in dir and returns its standard output
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// IsRepository reports whether root is inside a git work tree
+func IsRepository(ctx context.Context, root string) bool {
+	out, err := run(ctx, root, "rev-parse", "--is-inside-work-tree")
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// TopLevel returns the root directory of the git work tree containing root
+func TopLevel(ctx context.Context, root string) (string, error) {
+	out, err := run(ctx, root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
+
+// Blame returns the commit that last touched each of the given lines of
+// file, as of rev or the work tree if rev is "". Lines that are not
+// committed yet are left out.
+func Blame(ctx context.Context, root, rev, file string, lines []int) (map[int]model.GitInfo, error) {
+	// Scanned paths start with root, git wants them relative to it
+	rel := file
+	if r, err := filepath.Rel(root, file); err == nil {
+		rel = r
+	}
+
+	args := []string{"blame", "--porcelain"}
+	seen := make(map[int]bool)
+	for _, l := range lines {
+		if seen[l] {
+			continue
+		}
+		seen[l] = true
+		args = append(args, "-L", fmt.Sprintf("%d,%d", l, l))
+	}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	args = append(args, "--", filepath.ToSlash(rel))
+
+	out, err := run(ctx, root, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parsePorcelain(out)
+}
+
+// parsePorcelain reads the output of git blame --porcelain
+func parsePorcelain(out []byte) (map[int]model.GitInfo, error) {
+	result := make(map[int]model.GitInfo)
+	commits := make(map[string]*model.GitInfo)
+
+	var current *model.GitInfo
+	var finalLine int
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\t") {
+			// Content line ends the entry
+			if current != nil && current.Commit != uncommitted {
+				result[finalLine] = *current
+			}
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			fields := strings.Fields(line)
+			if len(fields) < 3 || len(fields[0]) != 40 {
+				return nil, fmt.Errorf("unexpected blame header %q", line)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected blame header %q", line)
+			}
+			finalLine = n
+			c, ok := commits[fields[0]]
+			if !ok {
+				c = &model.GitInfo{Commit: fields[0]}
+				commits[fields[0]] = c
+			}
+			current = c
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-mail":
+			current.AuthorEmail = strings.Trim(value, "<>")
+		case "author-time":
+			if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.Date = time.Unix(secs, 0).UTC()
+			}
+		}
+	}
+
+	return result, scanner.Err()
+}
+
+// ListFiles returns the files under root that git tracks or would track,
+// i.e. untracked files that are not ignored by .gitignore
+func ListFiles(ctx context.Context, root string) ([]string, error) {
+	if !IsRepository(ctx, root) {
+		return nil, ErrNotRepository
+	}
+
+	out, err := run(ctx, root, "ls-files", "--cached", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmerged files are listed once per stage
+	seen := make(map[string]bool)
+	files := []string{}
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		files = append(files, filepath.FromSlash(path))
+	}
+	return files, nil
+}
+
+// Change describes a file that differs between a base ref and the work tree
+type Change struct {
+	// Path is relative to the root passed to Changes
+	Path string
+	// Added holds the line numbers that were added or modified. Every line
+	// of an untracked file is new; Added is nil for those.
+	Added map[int]bool
+	// Untracked is set for files git does not know about yet
+	Untracked bool
+}
+
+// HasAddedLine reports whether line n was added or modified
+func (c Change) HasAddedLine(n int) bool {
+	return c.Untracked || c.Added[n]
+}
+
+// Changes lists the files under root that changed since the point where
+// the current branch diverged from ref, including uncommitted and untracked
+// files. Deleted files are left out.
+func Changes(ctx context.Context, root, ref string) ([]Change, error) {
+	if !IsRepository(ctx, root) {
+		return nil, ErrNotRepository
+	}
+
+	out, err := run(ctx, root, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSpace(string(out))
+
+	// Pin the prefixes and skip external diff drivers, which user config
+	// can change
+	out, err = run(ctx, root, "-c", "core.quotePath=false", "diff", "-U0", "--relative", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", base)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := parseDiff(out)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err = run(ctx, root, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" {
+			changes = append(changes, Change{Path: filepath.FromSlash(path), Untracked: true})
+		}
+	}
+
+	return changes, nil
+}
+
+// parseDiff reads the output of git diff -U0 and collects the added lines
+// of each file
+func parseDiff(out []byte) ([]Change, error) {
+	var changes []Change
+	var current *Change
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = nil
+		case strings.HasPrefix(line, "rename to "):
+			// A pure rename has no +++ line
+			changes = append(changes, Change{Path: diffPath(strings.TrimPrefix(line, "rename to ")), Added: map[int]bool{}})
+			current = &changes[len(changes)-1]
+		case strings.HasPrefix(line, "+++ "):
+			name := strings.TrimPrefix(line, "+++ ")
+			if name == "/dev/null" {
+				current = nil
+				continue
+			}
+			path := filepath.FromSlash(strings.TrimPrefix(unquote(name), "b/"))
+			if current == nil || current.Path != path {
+				changes = append(changes, Change{Path: path, Added: map[int]bool{}})
+				current = &changes[len(changes)-1]
+			}
+		case strings.HasPrefix(line, "@@ "):
+			if current == nil {
+				continue
+			}
+			start, count, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			for n := start; n < start+count; n++ {
+				current.Added[n] = true
+			}
+		}
+	}
+
+	return changes, scanner.Err()
+}
+
+// parseHunkHeader returns the new-file range of "@@ -a,b +c,d @@"
+func parseHunkHeader(line string) (int, int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, fmt.Errorf("unexpected hunk header %q", line)
+	}
+	startText, countText, hasCount := strings.Cut(fields[2][1:], ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected hunk header %q", line)
+	}
+	count := 1
+	if hasCount {
+		if count, err = strconv.Atoi(countText); err != nil {
+			return 0, 0, fmt.Errorf("unexpected hunk header %q", line)
+		}
+	}
+	return start, count, nil
+}
+
+// diffPath converts a path printed by git diff to a native relative path
+func diffPath(name string) string {
+	return filepath.FromSlash(unquote(name))
+}
+
+// unquote undoes git's C-style quoting of unusual file names
+func unquote(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		if s, err := strconv.Unquote(name); err == nil {
+			return s
+		}
+	}
+	return name
+}
@@ -0,0 +1,249 @@
+package gitinfo
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/jobin-404/debtbomb/internal/vfs"
+)
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "Ada Lovelace"},
+		{"config", "user.email", "ada@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		if _, err := run(context.Background(), dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	return dir
+}
+
+func TestBlame(t *testing.T) {
+	dir := gitRepo(t)
+	file := filepath.Join(dir, "main.go")
+	content := "package main\n\n// @debtbomb(expire=2026-01-01)\nfunc hack() {}\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(context.Background(), dir, "add", "main.go"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(context.Background(), dir, "commit", "-q", "-m", "add hack"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A line added after the commit has no blame information yet
+	if err := os.WriteFile(file, []byte(content+"// @debtbomb(expire=2026-01-01)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := Blame(context.Background(), dir, "", file, []int{3, 5})
+	if err != nil {
+		t.Fatalf("Blame failed: %v", err)
+	}
+
+	g, ok := info[3]
+	if !ok {
+		t.Fatalf("Expected git info for the committed line")
+	}
+	if g.Author != "Ada Lovelace" || g.AuthorEmail != "ada@example.com" {
+		t.Errorf("Expected author Ada Lovelace <ada@example.com>, got %s <%s>", g.Author, g.AuthorEmail)
+	}
+	if len(g.Commit) != 40 || g.Date.IsZero() {
+		t.Errorf("Expected commit hash and date, got %+v", g)
+	}
+	if gi, ok := info[5]; ok {
+		t.Errorf("Expected no git info for the uncommitted line, got %+v", gi)
+	}
+}
+
+func TestBlameOutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Blame(context.Background(), dir, "", "main.go", []int{1}); err == nil {
+		t.Error("Expected an error outside a repository")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	dir := gitRepo(t)
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("kept.go", "a\nb\nc\n")
+	write("edited.go", "a\nb\nc\n")
+	write("deleted.go", "a\n")
+	if _, err := run(context.Background(), dir, "add", "."); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(context.Background(), dir, "commit", "-q", "-m", "base"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(context.Background(), dir, "branch", "base"); err != nil {
+		t.Fatal(err)
+	}
+	// User settings must not change the diff that is parsed
+	if _, err := run(context.Background(), dir, "config", "diff.mnemonicPrefix", "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	write("edited.go", "a\nB\nc\nd\n")
+	write("new.go", "x\n")
+	if err := os.Remove(filepath.Join(dir, "deleted.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Changes(context.Background(), dir, "base")
+	if err != nil {
+		t.Fatalf("Changes failed: %v", err)
+	}
+
+	byPath := make(map[string]Change)
+	for _, c := range changes {
+		byPath[c.Path] = c
+	}
+	if len(byPath) != 2 {
+		t.Fatalf("Expected edited.go and new.go, got %+v", changes)
+	}
+
+	edited := byPath["edited.go"]
+	for line, want := range map[int]bool{1: false, 2: true, 3: false, 4: true} {
+		if edited.HasAddedLine(line) != want {
+			t.Errorf("edited.go line %d: expected added=%v", line, want)
+		}
+	}
+	if !byPath["new.go"].Untracked || !byPath["new.go"].HasAddedLine(1) {
+		t.Errorf("Expected new.go to be untracked with every line added, got %+v", byPath["new.go"])
+	}
+}
+
+func TestListFilesHonorsGitignore(t *testing.T) {
+	dir := gitRepo(t)
+	for name, content := range map[string]string{
+		".gitignore":     "gen/\n*.log\n!keep.log\n",
+		"main.go":        "package main\n",
+		"gen/out.go":     "package gen\n",
+		"debug.log":      "x\n",
+		"keep.log":       "x\n",
+		"sub/.gitignore": "local.go\n",
+		"sub/local.go":   "package sub\n",
+		"sub/shared.go":  "package sub\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ListFiles(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("ListFiles failed: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, f := range files {
+		got[filepath.ToSlash(f)] = true
+	}
+	for _, want := range []string{"main.go", "keep.log", "sub/shared.go"} {
+		if !got[want] {
+			t.Errorf("Expected %s to be listed, got %v", want, files)
+		}
+	}
+	for _, ignored := range []string{"gen/out.go", "debug.log", "sub/local.go"} {
+		if got[ignored] {
+			t.Errorf("Expected %s to be ignored", ignored)
+		}
+	}
+}
+
+func treeFS(root, rev string, load func(string) bool) (*vfs.MemFS, error) {
+	tree, err := ListTree(context.Background(), root, rev)
+	if err != nil {
+		return nil, err
+	}
+	return tree.FS(context.Background(), load)
+}
+
+func TestListTree(t *testing.T) {
+	dir := gitRepo(t)
+	for name, content := range map[string]string{
+		"main.go":     "package main\n",
+		"sub/util.go": "package sub\n",
+		"empty.txt":   "",
+		"big.txt":     "0123456789012345678901234567890\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"add", "."}, {"commit", "-q", "-m", "first"}, {"tag", "v1"}} {
+		if _, err := run(context.Background(), dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	// Later changes to the work tree are not seen
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := treeFS(filepath.Join(dir, "sub"), "v1", nil)
+	if err != nil {
+		t.Fatalf("ListTree failed: %v", err)
+	}
+	if data, err := fs.ReadFile(tree, "util.go"); err != nil || string(data) != "package sub\n" {
+		t.Errorf("Expected util.go relative to the root, got %q, %v", data, err)
+	}
+	if _, err := tree.Stat("main.go"); err == nil {
+		t.Error("Expected main.go outside the root to be left out")
+	}
+
+	tree, err = treeFS(dir, "v1", func(name string) bool { return name != "big.txt" })
+	if err != nil {
+		t.Fatalf("ListTree failed: %v", err)
+	}
+	if data, err := fs.ReadFile(tree, "main.go"); err != nil || string(data) != "package main\n" {
+		t.Errorf("Expected main.go as committed, got %q, %v", data, err)
+	}
+	if data, err := fs.ReadFile(tree, "empty.txt"); err != nil || len(data) != 0 {
+		t.Errorf("Expected empty.txt to be empty, got %q, %v", data, err)
+	}
+	// Files left out keep their size but not their content
+	if info, err := tree.Stat("big.txt"); err != nil || info.Size() != 32 {
+		t.Errorf("Expected big.txt with its size, got %v, %v", info, err)
+	}
+	if _, err := tree.Open("big.txt"); !errors.Is(err, vfs.ErrNotLoaded) {
+		t.Errorf("Expected big.txt not to be loaded, got %v", err)
+	}
+
+	if _, err := ListTree(context.Background(), dir, "no-such-rev"); err == nil {
+		t.Error("Expected an error for an unknown revision")
+	}
+}
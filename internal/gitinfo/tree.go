@@ -0,0 +1,177 @@
+package gitinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jobin-404/debtbomb/internal/vfs"
+)
+
+// treeFile is a blob listed by git ls-tree
+type treeFile struct {
+	path   string
+	object string
+	size   int64
+}
+
+// Tree lists the files under root as they are in a git revision
+type Tree struct {
+	root    string
+	modTime time.Time
+	files   []treeFile
+}
+
+// ListTree lists the files under root as they are in rev, without touching
+// the work tree. No content is read until FS is called. Symbolic links and
+// submodules are left out.
+func ListTree(ctx context.Context, root, rev string) (*Tree, error) {
+	if !IsRepository(ctx, root) {
+		return nil, ErrNotRepository
+	}
+
+	out, err := run(ctx, root, "show", "-s", "--format=%ct", rev+"^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	secs, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected commit time %q", out)
+	}
+
+	// Without --full-tree ls-tree lists the part of the tree below root
+	out, err = run(ctx, root, "ls-tree", "-r", "-z", "--long", rev)
+	if err != nil {
+		return nil, err
+	}
+	files, err := parseTree(out)
+	if err != nil {
+		return nil, err
+	}
+	return &Tree{root: root, modTime: time.Unix(secs, 0).UTC(), files: files}, nil
+}
+
+// FS returns the tree as an in-memory file system with paths relative to
+// root. Only the content of the files for which load returns true is read;
+// the others keep only their size, and opening them fails with
+// vfs.ErrNotLoaded. A nil load reads every file.
+func (t *Tree) FS(ctx context.Context, load func(name string) bool) (*vfs.MemFS, error) {
+	var wanted []treeFile
+	loaded := make(map[string]bool)
+	for _, f := range t.files {
+		if load == nil || load(f.path) {
+			wanted = append(wanted, f)
+			loaded[f.path] = true
+		}
+	}
+	contents, err := catFiles(ctx, t.root, wanted)
+	if err != nil {
+		return nil, err
+	}
+
+	m := vfs.New()
+	for _, f := range t.files {
+		var data []byte
+		if loaded[f.path] {
+			data = contents[f.object]
+		}
+		m.Add(f.path, f.size, t.modTime, data)
+	}
+	return m, nil
+}
+
+// parseTree reads the output of git ls-tree -r -z --long
+func parseTree(out []byte) ([]treeFile, error) {
+	var files []treeFile
+	for _, entry := range strings.Split(string(out), "\x00") {
+		if entry == "" {
+			continue
+		}
+		meta, path, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected ls-tree entry %q", entry)
+		}
+		mode, kind, object := fields[0], fields[1], fields[2]
+		if kind != "blob" || mode == "120000" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected ls-tree entry %q", entry)
+		}
+		files = append(files, treeFile{path: path, object: object, size: size})
+	}
+	return files, nil
+}
+
+// catFiles reads the content of the given blobs with a single
+// git cat-file --batch
+func catFiles(ctx context.Context, root string, files []treeFile) (map[string][]byte, error) {
+	contents := make(map[string][]byte, len(files))
+	if len(files) == 0 {
+		return contents, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", root, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		w := bufio.NewWriter(stdin)
+		for _, f := range files {
+			fmt.Fprintln(w, f.object)
+		}
+		w.Flush()
+		stdin.Close()
+	}()
+
+	r := bufio.NewReader(stdout)
+	for range files {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			cmd.Wait()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			cmd.Wait()
+			return nil, fmt.Errorf("git cat-file: unexpected header %q", header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			cmd.Wait()
+			return nil, fmt.Errorf("git cat-file: unexpected header %q", header)
+		}
+		data := make([]byte, size+1) // content and a trailing newline
+		if _, err := io.ReadFull(r, data); err != nil {
+			cmd.Wait()
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		contents[fields[0]] = data[:size]
+	}
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	return contents, nil
+}
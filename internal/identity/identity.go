@@ -0,0 +1,66 @@
+package identity
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+)
+
+// snippetLimit is how much of the snippet contributes to an ID
+const snippetLimit = 80
+
+// ID returns the generated identity of a bomb. Whitespace in the reason
+// and snippet is normalized so that reformatting does not change the ID.
+// The file is part of the ID, so a bomb that moves to another file gets a
+// new one; the Jira state follows it by its reason and snippet, and an
+// explicit id= attribute avoids the change altogether.
+func ID(file, reason, snippet string) string {
+	h := sha1.New()
+	h.Write([]byte(file))
+	h.Write([]byte{0})
+	h.Write([]byte(normalize(reason)))
+	h.Write([]byte{0})
+	h.Write([]byte(truncate(normalize(snippet))))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Occurrence returns the ID of the nth bomb in a file with the same
+// generated ID. The first keeps id, so that adding a copy of a bomb does not
+// change the identity of the original.
+func Occurrence(id string, n int) string {
+	if n <= 1 {
+		return id
+	}
+	return fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("%s\x00%d", id, n))))
+}
+
+// LegacyID returns the identity used before IDs were normalized, so that
+// state written by older versions can still be matched
+func LegacyID(file, reason, snippet string) string {
+	h := sha1.New()
+	h.Write([]byte(file))
+	h.Write([]byte(reason))
+	cleanSnippet := strings.TrimSpace(snippet)
+	if len(cleanSnippet) > snippetLimit {
+		cleanSnippet = cleanSnippet[:snippetLimit]
+	}
+	h.Write([]byte(cleanSnippet))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// SnippetHash fingerprints a snippet independently of its whitespace
+func SnippetHash(snippet string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(truncate(normalize(snippet)))))
+}
+
+// normalize collapses every run of whitespace into a single space
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncate(s string) string {
+	if len(s) > snippetLimit {
+		return s[:snippetLimit]
+	}
+	return s
+}
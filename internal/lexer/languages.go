@@ -0,0 +1,227 @@
+package lexer
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Delim is a pair of opening and closing tokens
+type Delim struct {
+	Open  string
+	Close string
+	// Escape is the escape character inside a string literal, or 0 if none
+	Escape byte
+	// Multiline reports whether a string literal may span lines
+	Multiline bool
+}
+
+// Language describes how comments and string literals are written in a family of files
+type Language struct {
+	Name          string
+	LineComments  []string
+	BlockComments []Delim
+	Strings       []Delim
+	// DocComments are string literals that count as block comments when
+	// nothing but whitespace precedes them on the line, e.g. Python docstrings
+	DocComments []Delim
+	// SpaceBeforeComment requires a line comment to start the line or follow whitespace
+	SpaceBeforeComment bool
+	// CharLiterals skips character literals such as 'x' and '\n' as code.
+	// They may hold a quote, but cannot be strings when a lone ' also
+	// starts a lifetime, as in Rust.
+	CharLiterals bool
+}
+
+var (
+	doubleQuoted = Delim{Open: `"`, Close: `"`, Escape: '\\'}
+	singleQuoted = Delim{Open: `'`, Close: `'`, Escape: '\\'}
+	rawSingle    = Delim{Open: `'`, Close: `'`}
+	cBlock       = Delim{Open: "/*", Close: "*/"}
+	tripleDouble = Delim{Open: `"""`, Close: `"""`, Escape: '\\', Multiline: true}
+	tripleSingle = Delim{Open: `'''`, Close: `'''`, Escape: '\\', Multiline: true}
+)
+
+var (
+	cLang = &Language{
+		Name:          "c",
+		LineComments:  []string{"//"},
+		BlockComments: []Delim{cBlock},
+		Strings:       []Delim{doubleQuoted, singleQuoted},
+	}
+	goLang = &Language{
+		Name:          "go",
+		LineComments:  []string{"//"},
+		BlockComments: []Delim{cBlock},
+		Strings:       []Delim{doubleQuoted, singleQuoted, {Open: "`", Close: "`", Multiline: true}},
+	}
+	jsLang = &Language{
+		Name:          "javascript",
+		LineComments:  []string{"//"},
+		BlockComments: []Delim{cBlock},
+		Strings:       []Delim{doubleQuoted, singleQuoted, {Open: "`", Close: "`", Escape: '\\', Multiline: true}},
+	}
+	jvmLang = &Language{
+		Name:          "jvm",
+		LineComments:  []string{"//"},
+		BlockComments: []Delim{cBlock},
+		Strings:       []Delim{tripleDouble, doubleQuoted, singleQuoted},
+	}
+	// Rust lifetimes ('a) look like unterminated character literals, so
+	// those are recognised whole instead of as strings
+	rustLang = &Language{
+		Name:          "rust",
+		LineComments:  []string{"//"},
+		BlockComments: []Delim{cBlock},
+		Strings: []Delim{
+			{Open: `"`, Close: `"`, Escape: '\\', Multiline: true},
+			{Open: `r"`, Close: `"`, Multiline: true},
+			{Open: `r#"`, Close: `"#`, Multiline: true},
+			{Open: `r##"`, Close: `"##`, Multiline: true},
+		},
+		CharLiterals: true,
+	}
+	phpLang = &Language{
+		Name:          "php",
+		LineComments:  []string{"//", "#"},
+		BlockComments: []Delim{cBlock},
+		Strings:       []Delim{doubleQuoted, singleQuoted},
+	}
+	cssLang = &Language{
+		Name:          "css",
+		BlockComments: []Delim{cBlock},
+		Strings:       []Delim{doubleQuoted, singleQuoted},
+	}
+	scssLang = &Language{
+		Name:          "scss",
+		LineComments:  []string{"//"},
+		BlockComments: []Delim{cBlock},
+		Strings:       []Delim{doubleQuoted, singleQuoted},
+	}
+	pythonLang = &Language{
+		Name:         "python",
+		LineComments: []string{"#"},
+		Strings:      []Delim{tripleDouble, tripleSingle, doubleQuoted, singleQuoted},
+		DocComments:  []Delim{{Open: `"""`, Close: `"""`}, {Open: `'''`, Close: `'''`}},
+	}
+	shellLang = &Language{
+		Name:               "shell",
+		LineComments:       []string{"#"},
+		Strings:            []Delim{doubleQuoted, rawSingle},
+		SpaceBeforeComment: true,
+	}
+	hashLang = &Language{
+		Name:         "hash",
+		LineComments: []string{"#"},
+		Strings:      []Delim{doubleQuoted, singleQuoted},
+	}
+	yamlLang = &Language{
+		Name:               "yaml",
+		LineComments:       []string{"#"},
+		Strings:            []Delim{doubleQuoted, rawSingle},
+		SpaceBeforeComment: true,
+	}
+	tomlLang = &Language{
+		Name:         "toml",
+		LineComments: []string{"#"},
+		Strings: []Delim{
+			tripleDouble,
+			{Open: `'''`, Close: `'''`, Multiline: true},
+			doubleQuoted,
+			rawSingle,
+		},
+	}
+	powershellLang = &Language{
+		Name:          "powershell",
+		LineComments:  []string{"#"},
+		BlockComments: []Delim{{Open: "<#", Close: "#>"}},
+		Strings:       []Delim{{Open: `"`, Close: `"`, Escape: '`'}, rawSingle},
+	}
+	sqlLang = &Language{
+		Name:          "sql",
+		LineComments:  []string{"--"},
+		BlockComments: []Delim{cBlock},
+		Strings:       []Delim{rawSingle, {Open: `"`, Close: `"`}},
+	}
+	luaLang = &Language{
+		Name:          "lua",
+		LineComments:  []string{"--"},
+		BlockComments: []Delim{{Open: "--[[", Close: "]]"}},
+		Strings:       []Delim{doubleQuoted, singleQuoted, {Open: "[[", Close: "]]", Multiline: true}},
+	}
+	haskellLang = &Language{
+		Name:          "haskell",
+		LineComments:  []string{"--"},
+		BlockComments: []Delim{{Open: "{-", Close: "-}"}},
+		Strings:       []Delim{doubleQuoted},
+	}
+	adaLang = &Language{
+		Name:         "ada",
+		LineComments: []string{"--"},
+		Strings:      []Delim{{Open: `"`, Close: `"`}},
+	}
+	lispLang = &Language{
+		Name:         "lisp",
+		LineComments: []string{";"},
+		Strings:      []Delim{doubleQuoted},
+	}
+	iniLang = &Language{
+		Name:               "ini",
+		LineComments:       []string{";", "#"},
+		SpaceBeforeComment: true,
+	}
+	erlangLang = &Language{
+		Name:         "erlang",
+		LineComments: []string{"%"},
+		Strings:      []Delim{doubleQuoted},
+	}
+	markupLang = &Language{
+		Name:          "markup",
+		BlockComments: []Delim{{Open: "<!--", Close: "-->"}},
+	}
+)
+
+var byExtension = map[string]*Language{
+	".c": cLang, ".h": cLang, ".cc": cLang, ".cpp": cLang, ".cxx": cLang, ".hpp": cLang, ".hh": cLang,
+	".m": cLang, ".mm": cLang, ".cs": cLang, ".proto": cLang, ".zig": cLang, ".d": cLang,
+	".go": goLang,
+	".js": jsLang, ".jsx": jsLang, ".mjs": jsLang, ".cjs": jsLang, ".ts": jsLang, ".tsx": jsLang, ".mts": jsLang, ".cts": jsLang,
+	".java": jvmLang, ".kt": jvmLang, ".kts": jvmLang, ".scala": jvmLang, ".groovy": jvmLang, ".gradle": jvmLang,
+	".swift": jvmLang, ".dart": jvmLang,
+	".rs":   rustLang,
+	".php":  phpLang,
+	".css":  cssLang,
+	".scss": scssLang, ".less": scssLang,
+	".py": pythonLang, ".pyi": pythonLang,
+	".sh": shellLang, ".bash": shellLang, ".zsh": shellLang, ".fish": shellLang,
+	".rb": hashLang, ".pl": hashLang, ".pm": hashLang, ".r": hashLang, ".ex": hashLang, ".exs": hashLang,
+	".nim": hashLang, ".cr": hashLang, ".tf": hashLang, ".cmake": hashLang, ".mk": hashLang,
+	".yml": yamlLang, ".yaml": yamlLang,
+	".toml": tomlLang,
+	".ps1":  powershellLang, ".psm1": powershellLang,
+	".sql": sqlLang,
+	".lua": luaLang,
+	".hs":  haskellLang, ".elm": haskellLang,
+	".adb": adaLang, ".ads": adaLang, ".vhd": adaLang, ".vhdl": adaLang,
+	".lisp": lispLang, ".el": lispLang, ".clj": lispLang, ".cljs": lispLang, ".scm": lispLang,
+	".ini": iniLang, ".cfg": iniLang,
+	".erl": erlangLang, ".hrl": erlangLang, ".tex": erlangLang,
+	".html": markupLang, ".htm": markupLang, ".xml": markupLang, ".svg": markupLang,
+	".md": markupLang, ".markdown": markupLang,
+}
+
+var byBaseName = map[string]*Language{
+	"dockerfile":  shellLang,
+	"makefile":    hashLang,
+	"gemfile":     hashLang,
+	"rakefile":    hashLang,
+	"jenkinsfile": jvmLang,
+}
+
+// ForFile returns the language for a file name, or nil if it is not known
+func ForFile(filename string) *Language {
+	base := strings.ToLower(filepath.Base(filename))
+	if lang, ok := byBaseName[base]; ok {
+		return lang
+	}
+	return byExtension[filepath.Ext(base)]
+}
@@ -0,0 +1,186 @@
+package lexer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Line is the lexed view of a single physical source line
+type Line struct {
+	Number   int
+	Text     string
+	Code     string // source text outside comments, trimmed
+	Comments []Comment
+}
+
+// Comment is the part of a comment that falls on one line
+type Comment struct {
+	Text   string // comment text without delimiters
+	Offset int    // byte offset of Text within the line
+	Block  bool   // part of a block comment
+}
+
+// Lexer splits source lines into code and comments. Block comments and
+// multi-line strings are carried over from one line to the next.
+type Lexer struct {
+	lang  *Language
+	line  int
+	block *Delim // open block comment, if any
+	str   *Delim // open string literal, if any
+}
+
+// New returns a Lexer for the given language
+func New(lang *Language) *Lexer {
+	return &Lexer{lang: lang}
+}
+
+type tokenKind int
+
+const (
+	tokNone tokenKind = iota
+	tokLineComment
+	tokBlockComment
+	tokString
+)
+
+// Next lexes the next physical line of input
+func (l *Lexer) Next(text string) Line {
+	l.line++
+	out := Line{Number: l.line, Text: text}
+
+	var code strings.Builder
+	i := 0
+	for i < len(text) {
+		switch {
+		case l.block != nil:
+			rest := text[i:]
+			end := strings.Index(rest, l.block.Close)
+			if end == -1 {
+				out.Comments = append(out.Comments, Comment{Text: rest, Offset: i, Block: true})
+				i = len(text)
+				continue
+			}
+			out.Comments = append(out.Comments, Comment{Text: rest[:end], Offset: i, Block: true})
+			i += end + len(l.block.Close)
+			l.block = nil
+
+		case l.str != nil:
+			j, closed := l.scanString(text, i)
+			code.WriteString(text[i:j])
+			if closed {
+				l.str = nil
+			}
+			i = j
+
+		default:
+			if l.lang.CharLiterals {
+				if n := charLiteral(text[i:]); n > 0 {
+					code.WriteString(text[i : i+n])
+					i += n
+					continue
+				}
+			}
+			kind, tok, d := l.match(text, i, strings.TrimSpace(code.String()) == "")
+			switch kind {
+			case tokLineComment:
+				out.Comments = append(out.Comments, Comment{Text: text[i+len(tok):], Offset: i + len(tok)})
+				i = len(text)
+			case tokBlockComment:
+				l.block = d
+				i += len(d.Open)
+			case tokString:
+				code.WriteString(d.Open)
+				l.str = d
+				i += len(d.Open)
+			default:
+				code.WriteByte(text[i])
+				i++
+			}
+		}
+	}
+
+	// Only some string literals may span lines
+	if l.str != nil && !l.str.Multiline {
+		l.str = nil
+	}
+
+	out.Code = strings.TrimSpace(code.String())
+	return out
+}
+
+// scanString advances through an open string literal starting at i and
+// reports where it stopped and whether the literal was closed
+func (l *Lexer) scanString(text string, i int) (int, bool) {
+	d := l.str
+	for i < len(text) {
+		if d.Escape != 0 && text[i] == d.Escape {
+			i += 2
+			continue
+		}
+		if strings.HasPrefix(text[i:], d.Close) {
+			return i + len(d.Close), true
+		}
+		i++
+	}
+	return len(text), false
+}
+
+// charLiteral returns the length of the character literal at the start of
+// s, or 0 if there is none, e.g. for a lifetime
+func charLiteral(s string) int {
+	if len(s) < 3 || s[0] != '\'' {
+		return 0
+	}
+	if s[1] == '\\' {
+		// The escaped character may itself be a quote: '\''
+		if end := strings.IndexByte(s[3:], '\''); end != -1 {
+			return 3 + end + 1
+		}
+		return 0
+	}
+	r, size := utf8.DecodeRuneInString(s[1:])
+	if r == '\'' || len(s) < 2+size || s[1+size] != '\'' {
+		return 0
+	}
+	return 2 + size
+}
+
+// match finds the longest comment or string token starting at position i.
+// lineStart reports whether only whitespace and comments precede i.
+func (l *Lexer) match(text string, i int, lineStart bool) (tokenKind, string, *Delim) {
+	rest := text[i:]
+	kind, tok, delim := tokNone, "", (*Delim)(nil)
+
+	for j := range l.lang.BlockComments {
+		d := &l.lang.BlockComments[j]
+		if strings.HasPrefix(rest, d.Open) && len(d.Open) > len(tok) {
+			kind, tok, delim = tokBlockComment, d.Open, d
+		}
+	}
+	for _, c := range l.lang.LineComments {
+		if !strings.HasPrefix(rest, c) || len(c) <= len(tok) {
+			continue
+		}
+		if l.lang.SpaceBeforeComment && i > 0 && text[i-1] != ' ' && text[i-1] != '\t' {
+			continue
+		}
+		kind, tok, delim = tokLineComment, c, nil
+	}
+	for j := range l.lang.Strings {
+		d := &l.lang.Strings[j]
+		if strings.HasPrefix(rest, d.Open) && len(d.Open) > len(tok) {
+			kind, tok, delim = tokString, d.Open, d
+		}
+	}
+
+	if lineStart {
+		for j := range l.lang.DocComments {
+			d := &l.lang.DocComments[j]
+			if strings.HasPrefix(rest, d.Open) && len(d.Open) >= len(tok) {
+				kind, tok, delim = tokBlockComment, d.Open, d
+			}
+		}
+	}
+
+	return kind, tok, delim
+}
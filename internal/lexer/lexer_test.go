@@ -0,0 +1,74 @@
+package lexer
+
+import "testing"
+
+func lexAll(lang *Language, lines ...string) []Line {
+	l := New(lang)
+	var out []Line
+	for _, text := range lines {
+		out = append(out, l.Next(text))
+	}
+	return out
+}
+
+func TestLexStringsAreCode(t *testing.T) {
+	lines := lexAll(goLang, `x := "// not a comment" // real`)
+
+	if lines[0].Code != `x := "// not a comment"` {
+		t.Errorf("Unexpected code %q", lines[0].Code)
+	}
+	if len(lines[0].Comments) != 1 || lines[0].Comments[0].Text != " real" {
+		t.Fatalf("Expected one comment ' real', got %+v", lines[0].Comments)
+	}
+}
+
+func TestLexBlockCommentAcrossLines(t *testing.T) {
+	lines := lexAll(cLang, "a(); /* one", " * two", " three */ b();")
+
+	if lines[0].Code != "a();" || lines[2].Code != "b();" {
+		t.Errorf("Unexpected code %q / %q", lines[0].Code, lines[2].Code)
+	}
+	if lines[1].Code != "" || len(lines[1].Comments) != 1 || !lines[1].Comments[0].Block {
+		t.Errorf("Expected middle line to be a block comment, got %+v", lines[1])
+	}
+	if lines[2].Comments[0].Text != " three " {
+		t.Errorf("Unexpected comment text %q", lines[2].Comments[0].Text)
+	}
+}
+
+func TestLexMultilineString(t *testing.T) {
+	lines := lexAll(pythonLang, `s = """`, `# inside`, `"""  # after`)
+
+	if len(lines[1].Comments) != 0 {
+		t.Errorf("Expected no comments inside docstring, got %+v", lines[1].Comments)
+	}
+	if len(lines[2].Comments) != 1 {
+		t.Errorf("Expected comment after docstring, got %+v", lines[2].Comments)
+	}
+}
+
+func TestLexSpaceBeforeComment(t *testing.T) {
+	lines := lexAll(yamlLang, "url: http://example.com/#anchor # note")
+
+	if len(lines[0].Comments) != 1 || lines[0].Comments[0].Text != " note" {
+		t.Errorf("Expected only the trailing comment, got %+v", lines[0].Comments)
+	}
+}
+
+func TestLexRustCharLiteralsAndRawStrings(t *testing.T) {
+	lines := lexAll(rustLang,
+		`let q = '"'; let e = '\''; fn f<'a>(s: &'a str) {}`,
+		`// @debtbomb(expire=2026-01-01)`,
+		`let r = r#"say "hi" // not a comment"#; // real`,
+	)
+
+	if len(lines[0].Comments) != 0 {
+		t.Errorf("Expected no comments on the first line, got %+v", lines[0].Comments)
+	}
+	if len(lines[1].Comments) != 1 {
+		t.Errorf("Expected the bomb after a quote character to be a comment, got %+v", lines[1])
+	}
+	if len(lines[2].Comments) != 1 || lines[2].Comments[0].Text != " real" {
+		t.Errorf("Expected only the comment after the raw string, got %+v", lines[2].Comments)
+	}
+}
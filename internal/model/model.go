@@ -1,21 +1,126 @@
 package model
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // DebtBomb represents a technical debt item found in the codebase
 type DebtBomb struct {
-	ID        string    `json:"id"`
-	File      string    `json:"file"`
-	Line      int       `json:"line"`
-	Expire    time.Time `json:"expire"`
-	Owner     string    `json:"owner,omitempty"`
-	Ticket    string    `json:"ticket,omitempty"`
-	Reason    string    `json:"reason,omitempty"`
-	Severity  string    `json:"severity,omitempty"`
-	RawText   string    `json:"rawText"`
-	Snippet   string    `json:"snippet"`
+	ID     string `json:"id"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Cell is the 1-based notebook cell holding the marker, in which case
+	// Line counts from the start of the cell. It is 0 for other files.
+	Cell   int       `json:"cell,omitempty"`
+	Expire time.Time `json:"expire"`
+	// ExpireExpr is the expire attribute as written, e.g. "+90d" or "2026-Q3"
+	ExpireExpr string `json:"expireExpr,omitempty"`
+	Owner      string `json:"owner,omitempty"`
+	Ticket     string `json:"ticket,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Severity   string `json:"severity,omitempty"`
+	// TimeZone is the tz attribute, an IANA name such as "Europe/Berlin".
+	// Dates without a time expire in this zone instead of the configured one.
+	TimeZone string `json:"tz,omitempty"`
+	RawText  string `json:"rawText"`
+	Snippet  string `json:"snippet"`
+	// Attributes holds every attribute without a dedicated field, e.g. component=billing
+	Attributes map[string]string `json:"attributes,omitempty"`
 
-	IsExpired bool      `json:"isExpired"`
+	// Git is set when the bomb was enriched from git blame
+	Git *GitInfo `json:"git,omitempty"`
+
+	IsExpired bool `json:"isExpired"`
+}
+
+// EOFSnippet is the Snippet of a bomb with no code after it before the
+// end of the file
+const EOFSnippet = "EOF"
+
+// GitInfo describes the commit that introduced a bomb
+type GitInfo struct {
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"authorEmail"`
+	Commit      string    `json:"commit"`
+	Date        time.Time `json:"date"`
+}
+
+// ShortCommit returns the abbreviated commit hash
+func (g GitInfo) ShortCommit() string {
+	if len(g.Commit) > 7 {
+		return g.Commit[:7]
+	}
+	return g.Commit
+}
+
+// Attribute returns a built-in field or custom attribute by key
+func (b DebtBomb) Attribute(key string) string {
+	switch key {
+	case "id":
+		return b.ID
+	case "expire":
+		return b.ExpireExpr
+	case "owner":
+		return b.Owner
+	case "ticket":
+		return b.Ticket
+	case "reason":
+		return b.Reason
+	case "severity":
+		return b.Severity
+	case "tz":
+		return b.TimeZone
+	}
+	return b.Attributes[key]
+}
+
+// Deadline returns the moment the bomb explodes. A bomb is valid through
+// its expire date, so a date expires at the start of the following day in
+// the bomb's time zone (the location of Expire). An RFC 3339 timestamp
+// expires at that instant.
+func (b DebtBomb) Deadline() time.Time {
+	if b.HasExpireTime() {
+		return b.Expire
+	}
+	return b.Expire.AddDate(0, 0, 1)
+}
+
+// HasExpireTime reports whether the expire attribute is an RFC 3339
+// timestamp rather than a date
+func (b DebtBomb) HasExpireTime() bool {
+	_, err := time.Parse(time.RFC3339, b.ExpireExpr)
+	return err == nil
+}
+
+// ExpiredAt reports whether the bomb has exploded at now
+func (b DebtBomb) ExpiredAt(now time.Time) bool {
+	return !now.Before(b.Deadline())
+}
+
+// DaysLeft returns the number of calendar days from now until the expire
+// date, counted in the bomb's time zone: 0 on the expire date itself and
+// negative once it has passed
+func (b DebtBomb) DaysLeft(now time.Time) int {
+	y, m, d := now.In(b.Expire.Location()).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	y, m, d = b.Expire.Date()
+	expire := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return int(expire.Sub(today).Hours() / 24)
+}
+
+// Location returns "file:line", or "file:cell N:line" inside a notebook
+func (b DebtBomb) Location() string {
+	return location(b.File, b.Cell, b.Line)
+}
+
+// Tags returns the comma or space separated values of the tags attribute
+func (b DebtBomb) Tags() []string {
+	return strings.FieldsFunc(b.Attributes["tags"], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
 }
 
 // DebtEvent represents a change in state of a DebtBomb
@@ -29,4 +134,44 @@ type DebtEvent struct {
 	Owner    string
 	Reason   string
 	Snippet  string
-}
\ No newline at end of file
+}
+
+// Diagnostic describes a malformed debtbomb marker
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Cell    int    `json:"cell,omitempty"`
+	Problem string `json:"problem"`
+	RawText string `json:"rawText"`
+}
+
+// Location returns "file:line", or "file:cell N:line" inside a notebook
+func (d Diagnostic) Location() string {
+	return location(d.File, d.Cell, d.Line)
+}
+
+func location(file string, cell, line int) string {
+	if cell > 0 {
+		return fmt.Sprintf("%s:cell %d:%d", file, cell, line)
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// Reasons a file was skipped by the scan
+const (
+	SkipTooLarge    = "too large"
+	SkipUnreadable  = "unreadable"
+	SkipLineTooLong = "line too long"
+	SkipBinary      = "binary"
+	// SkipMalformed is a structured file, such as a notebook, that does
+	// not parse
+	SkipMalformed = "malformed"
+)
+
+// SkippedFile is a file the scan did not read, or only read in part
+type SkippedFile struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
+}
@@ -43,19 +43,31 @@ func sendWebhook(url string, payload interface{}) error {
 func FormatExpiredMessage(bomb model.DebtBomb, ticketKey string) string {
 	msg := fmt.Sprintf("🚨 DebtBomb exploded\n%s\n%s\nOwner: %s\nExpires: %s",
 		bomb.File, bomb.Reason, bomb.Owner, bomb.Expire.Format("2006-01-02"))
-	
+
 	if bomb.Severity != "" {
 		msg += fmt.Sprintf("\nSeverity: %s", bomb.Severity)
 	}
-	
+
+	if bomb.Git != nil {
+		msg += fmt.Sprintf("\nIntroduced: %s by %s in %s",
+			bomb.Git.Date.Format("2006-01-02"), bomb.Git.Author, bomb.Git.ShortCommit())
+	}
+
 	if ticketKey != "" {
 		msg += fmt.Sprintf("\nJira: %s", ticketKey)
 	}
-	
+
 	return msg
 }
 
 func FormatWarningMessage(bomb model.DebtBomb, daysLeft int) string {
-	return fmt.Sprintf("⏳ DebtBomb warning (%d days left)\n%s\n%s\nOwner: %s\nExpires: %s",
+	msg := fmt.Sprintf("⏳ DebtBomb warning (%d days left)\n%s\n%s\nOwner: %s\nExpires: %s",
 		daysLeft, bomb.File, bomb.Reason, bomb.Owner, bomb.Expire.Format("2006-01-02"))
+
+	if bomb.Git != nil {
+		msg += fmt.Sprintf("\nIntroduced: %s by %s in %s",
+			bomb.Git.Date.Format("2006-01-02"), bomb.Git.Author, bomb.Git.ShortCommit())
+	}
+
+	return msg
 }
@@ -2,8 +2,8 @@ package notify
 
 import (
 	"fmt"
-	"time"
 
+	"github.com/jobin-404/debtbomb/internal/clock"
 	"github.com/jobin-404/debtbomb/internal/config"
 	"github.com/jobin-404/debtbomb/internal/jira"
 	"github.com/jobin-404/debtbomb/internal/model"
@@ -14,10 +14,17 @@ type Router struct {
 	Config *config.Config
 	Jira   *jira.Client
 	State  *state.State
+	// Clock decides how many days are left; nil means the system clock
+	Clock clock.Clock
 }
 
 func (r *Router) SyncAndNotify(bombs []model.DebtBomb, checkDays int, expiredOnly bool) error {
-	today := time.Now().Truncate(24 * time.Hour)
+	now := clock.OrSystem(r.Clock).Now()
+
+	// Follow bombs whose ID changed (file moved, reason or code edited)
+	for _, rk := range r.State.Migrate(bombs) {
+		fmt.Printf("Re-keyed ticket %s from %s to %s\n", rk.Ticket, rk.From, rk.To)
+	}
 
 	// Separate bombs
 	var expired []model.DebtBomb
@@ -35,7 +42,7 @@ func (r *Router) SyncAndNotify(bombs []model.DebtBomb, checkDays int, expiredOnl
 				continue
 			}
 			// Check if expiring soon
-			daysLeft := int(b.Expire.Sub(today).Hours() / 24)
+			daysLeft := b.DaysLeft(now)
 			if daysLeft >= 0 {
 				if checkDays > 0 && daysLeft > checkDays {
 					continue
@@ -78,9 +85,13 @@ func (r *Router) SyncAndNotify(bombs []model.DebtBomb, checkDays int, expiredOnl
 		}
 	}
 
+	for _, b := range expired {
+		r.State.Track(b)
+	}
+
 	// 2. Process Expiring Bombs (Notify only)
 	for _, b := range expiring {
-		daysLeft := int(b.Expire.Sub(today).Hours() / 24)
+		daysLeft := b.DaysLeft(now)
 		r.notifyExpiringSoon(b, daysLeft)
 	}
 
@@ -123,20 +134,30 @@ func (r *Router) createJiraTicket(b model.DebtBomb) (string, error) {
 	project := r.Config.Jira.DefaultProject
 	issueType := r.Config.Jira.IssueType
 
-	summary := fmt.Sprintf("Expired tech debt: %s", b.Reason)
-	description := fmt.Sprintf("File: %s\nExpires: %s\nOwner: %s\nSeverity: %s\n\nSnippet:\n%s",
-		b.File, b.Expire.Format("2006-01-02"), b.Owner, b.Severity, b.Snippet)
+	data := TemplateData{DebtBomb: b}
+	summary := render("jira_summary", r.Config.Templates.JiraSummary, data,
+		fmt.Sprintf("Expired tech debt: %s", b.Reason))
+	introduced := ""
+	if b.Git != nil {
+		introduced = fmt.Sprintf("\nIntroduced: %s by %s <%s> in %s",
+			b.Git.Date.Format("2006-01-02"), b.Git.Author, b.Git.AuthorEmail, b.Git.Commit)
+	}
+	description := render("jira_description", r.Config.Templates.JiraDescription, data,
+		fmt.Sprintf("File: %s\nExpires: %s\nOwner: %s\nSeverity: %s%s\n\nSnippet:\n%s",
+			b.File, b.Expire.Format("2006-01-02"), b.Owner, b.Severity, introduced, b.Snippet))
 
 	return r.Jira.CreateTicket(project, summary, description, issueType, b.Severity)
 }
 
 func (r *Router) notifyExpired(b model.DebtBomb, ticketKey string) {
-	msg := FormatExpiredMessage(b, ticketKey)
+	msg := render("expired", r.Config.Templates.Expired,
+		TemplateData{DebtBomb: b, TicketKey: ticketKey}, FormatExpiredMessage(b, ticketKey))
 	r.sendNotifications("expired", 0, msg)
 }
 
 func (r *Router) notifyExpiringSoon(b model.DebtBomb, daysLeft int) {
-	msg := FormatWarningMessage(b, daysLeft)
+	msg := render("expiring_soon", r.Config.Templates.ExpiringSoon,
+		TemplateData{DebtBomb: b, TicketKey: r.State.GetTicket(b.ID), DaysLeft: daysLeft}, FormatWarningMessage(b, daysLeft))
 	r.sendNotifications("expiring_soon", daysLeft, msg)
 }
 
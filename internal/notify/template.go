@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+// TemplateData is what notification and Jira templates can refer to, e.g.
+//
+//	{{.File}}:{{.Line}} owned by {{.Owner}} for {{.Attribute "component"}}
+//	expires {{date .Expire}} ({{.DaysLeft}} days left), ticket {{.TicketKey}}
+type TemplateData struct {
+	model.DebtBomb
+	TicketKey string
+	DaysLeft  int
+}
+
+var templateFuncs = template.FuncMap{
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02")
+	},
+	"join": strings.Join,
+}
+
+// RenderTemplate executes a user supplied template against data
+func RenderTemplate(text string, data TemplateData) (string, error) {
+	tmpl, err := template.New("message").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// render uses the configured template if there is one, falling back to
+// the built-in text when it is empty or broken
+func render(name, text string, data TemplateData, fallback string) string {
+	if text == "" {
+		return fallback
+	}
+	out, err := RenderTemplate(text, data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid %s template: %v\n", name, err)
+		return fallback
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+// GitHubOptions controls WriteGitHubAnnotations
+type GitHubOptions struct {
+	// WarnDays is the warning window the warning bombs were picked with
+	WarnDays int
+	// AllowMalformed annotates malformed markers as warnings instead of
+	// errors, matching check --allow-malformed
+	AllowMalformed bool
+	// Path maps a reported file to its path from the repository root,
+	// where GitHub looks for it; nil keeps the reported path
+	Path func(string) string
+}
+
+// WriteGitHubAnnotations writes a GitHub Actions workflow command for each
+// expired bomb (::error), bomb in the warning window (::warning) and
+// malformed marker, so that they show up inline on the pull request diff.
+func WriteGitHubAnnotations(w io.Writer, expiredBombs []model.DebtBomb, warningBombs []model.DebtBomb, diagnostics []model.Diagnostic, opts GitHubOptions) {
+	path := opts.Path
+	if path == nil {
+		path = func(file string) string { return file }
+	}
+	for _, b := range expiredBombs {
+		msg := fmt.Sprintf("Expired on %s", b.Expire.Format("2006-01-02"))
+		writeAnnotation(w, "error", "Debt bomb expired", path(b.File), b.Cell, b.Line, b.Column, annotationMessage(msg, b))
+	}
+	for _, b := range warningBombs {
+		msg := fmt.Sprintf("Expires on %s, within %d days", b.Expire.Format("2006-01-02"), opts.WarnDays)
+		writeAnnotation(w, "warning", "Debt bomb expiring soon", path(b.File), b.Cell, b.Line, b.Column, annotationMessage(msg, b))
+	}
+	level := "error"
+	if opts.AllowMalformed {
+		level = "warning"
+	}
+	for _, d := range diagnostics {
+		writeAnnotation(w, level, "Malformed debt bomb", path(d.File), d.Cell, d.Line, d.Column, d.Problem)
+	}
+}
+
+func annotationMessage(msg string, b model.DebtBomb) string {
+	if b.Owner != "" {
+		msg += fmt.Sprintf(" (owner: %s)", b.Owner)
+	}
+	if b.Reason != "" {
+		msg += ": " + b.Reason
+	}
+	if b.Ticket != "" {
+		msg += "\nTicket: " + b.Ticket
+	}
+	return msg
+}
+
+// writeAnnotation writes one workflow command. Lines inside a notebook
+// cell are not lines of the file, so only the file is given for those.
+func writeAnnotation(w io.Writer, level, title, file string, cell, line, column int, msg string) {
+	props := []string{"file=" + escapeProperty(filepath.ToSlash(file))}
+	if cell == 0 {
+		props = append(props, fmt.Sprintf("line=%d", line))
+		if column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", column))
+		}
+	} else {
+		msg = fmt.Sprintf("cell %d, line %d: %s", cell, line, msg)
+	}
+	props = append(props, "title="+escapeProperty(title))
+	fmt.Fprintf(w, "::%s %s::%s\n", level, strings.Join(props, ","), escapeData(msg))
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// ownerCounts is one row of the job summary
+type ownerCounts struct {
+	owner                    string
+	total, expired, expiring int
+}
+
+// WriteGitHubSummary writes a Markdown job summary for
+// $GITHUB_STEP_SUMMARY: the totals and the counts by owner.
+func WriteGitHubSummary(w io.Writer, bombs []model.DebtBomb, warningBombs []model.DebtBomb, warnDays int, diagnostics []model.Diagnostic) {
+	warned := make(map[string]bool, len(warningBombs))
+	for _, b := range warningBombs {
+		warned[b.Location()] = true
+	}
+
+	byOwner := make(map[string]*ownerCounts)
+	expired := 0
+	for _, b := range bombs {
+		owner := b.Owner
+		if owner == "" {
+			owner = "(no owner)"
+		}
+		c, ok := byOwner[owner]
+		if !ok {
+			c = &ownerCounts{owner: owner}
+			byOwner[owner] = c
+		}
+		c.total++
+		if b.IsExpired {
+			c.expired++
+			expired++
+		} else if warned[b.Location()] {
+			c.expiring++
+		}
+	}
+
+	rows := make([]*ownerCounts, 0, len(byOwner))
+	for _, c := range byOwner {
+		rows = append(rows, c)
+	}
+	// Most urgent first
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].expired != rows[j].expired {
+			return rows[i].expired > rows[j].expired
+		}
+		if rows[i].expiring != rows[j].expiring {
+			return rows[i].expiring > rows[j].expiring
+		}
+		if rows[i].total != rows[j].total {
+			return rows[i].total > rows[j].total
+		}
+		return rows[i].owner < rows[j].owner
+	})
+
+	if expired > 0 {
+		fmt.Fprintf(w, "## DebtBomb exploded: %d expired\n\n", expired)
+	} else {
+		fmt.Fprint(w, "## DebtBomb: nothing expired\n\n")
+	}
+
+	fmt.Fprintln(w, "| | Count |")
+	fmt.Fprintln(w, "|---|---:|")
+	fmt.Fprintf(w, "| Expired | %d |\n", expired)
+	if warnDays > 0 {
+		fmt.Fprintf(w, "| Expiring within %d days | %d |\n", warnDays, len(warningBombs))
+	}
+	if len(diagnostics) > 0 {
+		fmt.Fprintf(w, "| Malformed | %d |\n", len(diagnostics))
+	}
+	fmt.Fprintf(w, "| Total | %d |\n", len(bombs))
+	// Other steps append their summaries after this one
+	defer fmt.Fprintln(w)
+
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "### By owner")
+	fmt.Fprintln(w)
+	if warnDays > 0 {
+		fmt.Fprintln(w, "| Owner | Expired | Expiring | Total |")
+		fmt.Fprintln(w, "|---|---:|---:|---:|")
+	} else {
+		fmt.Fprintln(w, "| Owner | Expired | Total |")
+		fmt.Fprintln(w, "|---|---:|---:|")
+	}
+	for _, c := range rows {
+		if warnDays > 0 {
+			fmt.Fprintf(w, "| %s | %d | %d | %d |\n", escapeMarkdownCell(c.owner), c.expired, c.expiring, c.total)
+		} else {
+			fmt.Fprintf(w, "| %s | %d | %d |\n", escapeMarkdownCell(c.owner), c.expired, c.total)
+		}
+	}
+}
+
+// escapeMarkdownCell keeps a value from breaking out of its table cell
+func escapeMarkdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
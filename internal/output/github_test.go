@@ -0,0 +1,70 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	expired := []model.DebtBomb{
+		{File: "src/a,b.go", Line: 3, Column: 4, Expire: day("2026-09-30"), Owner: "payments", Reason: "100% hack\nfor now"},
+	}
+	warning := []model.DebtBomb{
+		{File: "nb.ipynb", Cell: 2, Line: 1, Column: 3, Expire: day("2026-10-03")},
+	}
+	diagnostics := []model.Diagnostic{{File: "c.go", Line: 7, Column: 2, Problem: "missing expire date"}}
+
+	var buf bytes.Buffer
+	WriteGitHubAnnotations(&buf, expired, warning, diagnostics, GitHubOptions{WarnDays: 7})
+
+	want := `::error file=src/a%2Cb.go,line=3,col=4,title=Debt bomb expired::Expired on 2026-09-30 (owner: payments): 100%25 hack%0Afor now
+::warning file=nb.ipynb,title=Debt bomb expiring soon::cell 2, line 1: Expires on 2026-10-03, within 7 days
+::error file=c.go,line=7,col=2,title=Malformed debt bomb::missing expire date
+`
+	if buf.String() != want {
+		t.Errorf("Unexpected annotations:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteGitHubAnnotationsOptions(t *testing.T) {
+	diagnostics := []model.Diagnostic{{File: "c.go", Line: 7, Problem: "missing expire date"}}
+
+	var buf bytes.Buffer
+	WriteGitHubAnnotations(&buf, nil, nil, diagnostics, GitHubOptions{
+		AllowMalformed: true,
+		Path:           func(file string) string { return "sub/" + file },
+	})
+
+	want := "::warning file=sub/c.go,line=7,title=Malformed debt bomb::missing expire date\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected annotations:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteGitHubSummary(t *testing.T) {
+	bombs := []model.DebtBomb{
+		{File: "a.go", Line: 1, Owner: "web"},
+		{File: "b.go", Line: 1, Owner: "payments", IsExpired: true},
+		{File: "c.go", Line: 1, Owner: "payments"},
+		{File: "d.go", Line: 1},
+	}
+	warning := bombs[2:3]
+
+	var buf bytes.Buffer
+	WriteGitHubSummary(&buf, bombs, warning, 7, nil)
+
+	for _, want := range []string{
+		"## DebtBomb exploded: 1 expired\n",
+		"| Expiring within 7 days | 1 |\n",
+		"| Total | 4 |\n",
+		// Owners with expired debt come first
+		"| payments | 1 | 1 | 2 |\n| (no owner) | 0 | 0 | 1 |\n| web | 0 | 0 | 1 |\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
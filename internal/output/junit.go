@@ -0,0 +1,199 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+// Ways to group bombs into JUnit test suites
+const (
+	JUnitByOwner  = "owner"
+	JUnitByFolder = "folder"
+)
+
+// JUnitOptions controls WriteJUnit
+type JUnitOptions struct {
+	// GroupBy is JUnitByOwner or JUnitByFolder; "" means by owner
+	GroupBy string
+	// WarnDays is the warning window the warning bombs were picked with
+	WarnDays int
+	// SkipWarnings reports bombs in the warning window as skipped tests
+	// instead of passed ones
+	SkipWarnings bool
+	// AllowMalformed reports malformed markers as skipped tests instead of
+	// failures, matching check --allow-malformed
+	AllowMalformed bool
+}
+
+// malformedSuite holds the markers that could not be parsed
+const malformedSuite = "malformed"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",cdata"`
+}
+
+// PrintJUnit prints the check result as JUnit XML
+func PrintJUnit(bombs, warningBombs []model.DebtBomb, diagnostics []model.Diagnostic, opts JUnitOptions) {
+	if err := WriteJUnit(os.Stdout, bombs, warningBombs, diagnostics, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode junit: %v\n", err)
+	}
+}
+
+// WriteJUnit writes the check result as JUnit XML for CI test reporters.
+// Every bomb is a test case: expired bombs fail, and bombs listed in
+// warningBombs pass or, with SkipWarnings, are skipped. Malformed markers
+// go into a suite of their own.
+func WriteJUnit(w io.Writer, bombs, warningBombs []model.DebtBomb, diagnostics []model.Diagnostic, opts JUnitOptions) error {
+	warned := make(map[string]bool, len(warningBombs))
+	for _, b := range warningBombs {
+		warned[b.Location()] = true
+	}
+
+	suites := make(map[string]*junitTestSuite)
+	suite := func(name string) *junitTestSuite {
+		s, ok := suites[name]
+		if !ok {
+			s = &junitTestSuite{Name: name, Time: "0"}
+			suites[name] = s
+		}
+		return s
+	}
+
+	for _, b := range bombs {
+		s := suite(junitGroup(b, opts.GroupBy))
+		tc := junitTestCase{Name: b.Location(), ClassName: s.Name, Time: "0"}
+		switch {
+		case b.IsExpired:
+			tc.Failure = &junitMessage{
+				Message: junitReason(fmt.Sprintf("Expired on %s", b.Expire.Format("2006-01-02")), b),
+				Type:    RuleExpired,
+				Text:    junitDetails(b),
+			}
+			s.Failures++
+		case warned[b.Location()] && opts.SkipWarnings:
+			tc.Skipped = &junitMessage{
+				Message: junitReason(fmt.Sprintf("Warning: expires on %s, within %d days", b.Expire.Format("2006-01-02"), opts.WarnDays), b),
+				Text:    junitDetails(b),
+			}
+			s.Skipped++
+		}
+		s.Tests++
+		s.Cases = append(s.Cases, tc)
+	}
+
+	for _, d := range diagnostics {
+		s := suite(malformedSuite)
+		msg := &junitMessage{Message: "Malformed: " + d.Problem, Type: RuleMalformed, Text: d.RawText}
+		tc := junitTestCase{Name: d.Location(), ClassName: s.Name, Time: "0"}
+		if opts.AllowMalformed {
+			tc.Skipped = msg
+			s.Skipped++
+		} else {
+			tc.Failure = msg
+			s.Failures++
+		}
+		s.Tests++
+		s.Cases = append(s.Cases, tc)
+	}
+
+	out := junitTestSuites{Name: "debtbomb", Suites: []junitTestSuite{}}
+	for _, s := range suites {
+		out.Tests += s.Tests
+		out.Failures += s.Failures
+		out.Skipped += s.Skipped
+		out.Suites = append(out.Suites, *s)
+	}
+	sort.Slice(out.Suites, func(i, j int) bool { return out.Suites[i].Name < out.Suites[j].Name })
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitGroup returns the suite of a bomb: its owner, or its folder with
+// forward slashes, named as in the report command
+func junitGroup(b model.DebtBomb, groupBy string) string {
+	if groupBy == JUnitByFolder {
+		dir := filepath.Dir(b.File)
+		if dir == "." {
+			return "(root)"
+		}
+		return filepath.ToSlash(dir)
+	}
+	if b.Owner == "" {
+		return "(no owner)"
+	}
+	return b.Owner
+}
+
+func junitReason(msg string, b model.DebtBomb) string {
+	if b.Reason != "" {
+		msg += ": " + b.Reason
+	}
+	return msg
+}
+
+// junitDetails is the body of a failure: who to ask and the code the bomb
+// sits on
+func junitDetails(b model.DebtBomb) string {
+	var lines []string
+	if b.Owner != "" {
+		lines = append(lines, "Owner: "+b.Owner)
+	}
+	if b.Ticket != "" {
+		lines = append(lines, "Ticket: "+b.Ticket)
+	}
+	if b.Git != nil {
+		lines = append(lines, fmt.Sprintf("Introduced: %s by %s (%s)", b.Git.Date.Format("2006-01-02"), b.Git.Author, b.Git.ShortCommit()))
+	}
+	if b.Snippet != "" {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, b.Snippet)
+	}
+	return strings.Join(lines, "\n")
+}
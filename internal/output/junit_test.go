@@ -0,0 +1,73 @@
+package output
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	bombs := []model.DebtBomb{
+		{File: "src/a.go", Line: 3, Expire: day("2026-09-30"), Owner: "payments", Reason: "retry loop", Snippet: "retry()", IsExpired: true},
+		{File: "src/b.go", Line: 5, Expire: day("2026-10-03"), Owner: "payments"},
+		{File: "c.go", Line: 1, Expire: day("2027-01-01")},
+	}
+	warning := bombs[1:2]
+	diagnostics := []model.Diagnostic{{File: "d.go", Line: 2, Problem: "missing expire date", RawText: "// @debtbomb()"}}
+
+	for _, tt := range []struct {
+		name   string
+		opts   JUnitOptions
+		suites []string
+		// failures and skipped over every suite
+		failures, skipped int
+	}{
+		{"by owner", JUnitOptions{}, []string{"(no owner)", "malformed", "payments"}, 2, 0},
+		{"by folder", JUnitOptions{GroupBy: JUnitByFolder}, []string{"(root)", "malformed", "src"}, 2, 0},
+		{"skip warnings", JUnitOptions{SkipWarnings: true, WarnDays: 7}, []string{"(no owner)", "malformed", "payments"}, 2, 1},
+		{"allow malformed", JUnitOptions{AllowMalformed: true}, []string{"(no owner)", "malformed", "payments"}, 1, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteJUnit(&buf, bombs, warning, diagnostics, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			var out junitTestSuites
+			if err := xml.Unmarshal(buf.Bytes(), &out); err != nil {
+				t.Fatalf("Invalid XML: %v\n%s", err, buf.String())
+			}
+
+			var names []string
+			for _, s := range out.Suites {
+				names = append(names, s.Name)
+			}
+			if len(names) != len(tt.suites) {
+				t.Fatalf("Expected suites %v, got %v", tt.suites, names)
+			}
+			for i := range names {
+				if names[i] != tt.suites[i] {
+					t.Fatalf("Expected suites %v, got %v", tt.suites, names)
+				}
+			}
+			if out.Tests != 4 || out.Failures != tt.failures || out.Skipped != tt.skipped {
+				t.Errorf("Expected 4 tests, %d failures and %d skipped, got %d, %d and %d",
+					tt.failures, tt.skipped, out.Tests, out.Failures, out.Skipped)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, bombs[:1], nil, nil, JUnitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var out junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	failure := out.Suites[0].Cases[0].Failure
+	if failure == nil || failure.Message != "Expired on 2026-09-30: retry loop" || failure.Text != "Owner: payments\n\nretry()" {
+		t.Errorf("Unexpected failure %+v", failure)
+	}
+}
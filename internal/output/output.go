@@ -3,30 +3,50 @@ package output
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/jobin-404/debtbomb/internal/clock"
 	"github.com/jobin-404/debtbomb/internal/model"
 	"github.com/jobin-404/debtbomb/internal/report"
 )
 
 type jsonOutput struct {
-	HasExpired bool       `json:"hasExpired"`
-	Bombs      []jsonBomb `json:"bombs"`
+	HasExpired  bool                `json:"hasExpired"`
+	Bombs       []jsonBomb          `json:"bombs"`
+	Diagnostics []model.Diagnostic  `json:"diagnostics,omitempty"`
+	Skipped     []model.SkippedFile `json:"skipped,omitempty"`
 }
 
 type jsonBomb struct {
-	File   string `json:"file"`
-	Line   int    `json:"line"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Cell is the 1-based notebook cell, when the bomb is in one
+	Cell   int    `json:"cell,omitempty"`
 	Expire string `json:"expire"`
-	Owner  string `json:"owner,omitempty"`
-	Ticket string `json:"ticket,omitempty"`
-	Reason string `json:"reason,omitempty"`
+	// ExpireExpr is only set when the bomb was written with something other than a plain date
+	ExpireExpr string            `json:"expireExpr,omitempty"`
+	Owner      string            `json:"owner,omitempty"`
+	Ticket     string            `json:"ticket,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Author     string            `json:"author,omitempty"`
+	Commit     string            `json:"commit,omitempty"`
+	Introduced string            `json:"introduced,omitempty"`
 }
 
 // PrintJSON prints the report in JSON format
-func PrintJSON(bombs []model.DebtBomb) {
+func PrintJSON(bombs []model.DebtBomb, diagnostics []model.Diagnostic, skipped []model.SkippedFile) {
+	if err := WriteJSON(os.Stdout, bombs, diagnostics, skipped); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode json: %v\n", err)
+	}
+}
+
+// WriteJSON writes the scan result in JSON format
+func WriteJSON(w io.Writer, bombs []model.DebtBomb, diagnostics []model.Diagnostic, skipped []model.SkippedFile) error {
 	hasExpired := false
 	outputBombs := make([]jsonBomb, 0, len(bombs))
 
@@ -34,140 +54,270 @@ func PrintJSON(bombs []model.DebtBomb) {
 		if b.IsExpired {
 			hasExpired = true
 		}
-		outputBombs = append(outputBombs, jsonBomb{
-			File:   b.File,
-			Line:   b.Line,
-			Expire: b.Expire.Format("2006-01-02"),
-			Owner:  b.Owner,
-			Ticket: b.Ticket,
-			Reason: b.Reason,
-		})
+		outputBombs = append(outputBombs, newJSONBomb(b))
 	}
 
 	out := jsonOutput{
-		HasExpired: hasExpired,
-		Bombs:      outputBombs,
+		HasExpired:  hasExpired,
+		Bombs:       outputBombs,
+		Diagnostics: diagnostics,
+		Skipped:     skipped,
 	}
 
-	encoder := json.NewEncoder(os.Stdout)
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(out); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to encode json: %v\n", err)
+	return encoder.Encode(out)
+}
+
+// WriteNDJSONBomb writes b as a single line of JSON, with the fields of
+// the bombs in WriteJSON and whether it is expired
+func WriteNDJSONBomb(w io.Writer, b model.DebtBomb) error {
+	line := struct {
+		jsonBomb
+		Expired bool `json:"expired"`
+	}{newJSONBomb(b), b.IsExpired}
+	return json.NewEncoder(w).Encode(line)
+}
+
+func newJSONBomb(b model.DebtBomb) jsonBomb {
+	jb := jsonBomb{
+		File:       b.File,
+		Line:       b.Line,
+		Cell:       b.Cell,
+		Expire:     b.Expire.Format("2006-01-02"),
+		ExpireExpr: writtenExpire(b),
+		Owner:      b.Owner,
+		Ticket:     b.Ticket,
+		Reason:     b.Reason,
+		Attributes: b.Attributes,
+	}
+	if b.Git != nil {
+		jb.Author = b.Git.Author
+		jb.Commit = b.Git.Commit
+		jb.Introduced = b.Git.Date.Format("2006-01-02")
 	}
+	return jb
 }
 
 // PrintTable prints a clean ASCII table for the list command
-func PrintTable(bombs []model.DebtBomb) {
-	fmt.Printf("Found %d DebtBombs\n", len(bombs))
+func PrintTable(bombs []model.DebtBomb, clk clock.Clock) {
+	WriteTable(os.Stdout, bombs, clk)
+}
+
+// WriteTable writes the list command table. The time left is measured
+// against clk.
+func WriteTable(w io.Writer, bombs []model.DebtBomb, clk clock.Clock) {
+	now := clk.Now()
+	fmt.Fprintf(w, "Found %d DebtBombs\n", len(bombs))
 	if len(bombs) == 0 {
 		return
 	}
 
-	// Headers
-	hExpires := "Expires"
-	hOwner := "Owner"
-	hTicket := "Ticket"
-	hLocation := "Location"
+	// The Author column is only shown when git blame data is available
+	withGit := false
+	for _, b := range bombs {
+		if b.Git != nil {
+			withGit = true
+			break
+		}
+	}
 
-	// Initial widths based on headers
-	wExpires := len(hExpires)
-	wOwner := len(hOwner)
-	wTicket := len(hTicket)
-	wLocation := len(hLocation)
+	headers := []string{"Expires", "Owner", "Ticket", "Location"}
+	if withGit {
+		headers = []string{"Expires", "Owner", "Author", "Ticket", "Location"}
+	}
 
-	// Calculate max widths
+	rows := make([][]string, 0, len(bombs))
 	for _, b := range bombs {
-		dateStr := b.Expire.Format("2006-01-02")
-		timeLeftStr := timeLeft(b.Expire)
-		fullExpiresStr := fmt.Sprintf("%s %s", dateStr, timeLeftStr)
-		if len(fullExpiresStr) > wExpires {
-			wExpires = len(fullExpiresStr)
-		}
-		if len(b.Owner) > wOwner {
-			wOwner = len(b.Owner)
-		}
-		if len(b.Ticket) > wTicket {
-			wTicket = len(b.Ticket)
+		expiresWithTime := fmt.Sprintf("%s %s", b.Expire.Format("2006-01-02"), timeLeft(b.Deadline(), now))
+		location := b.Location()
+		if withGit {
+			author := ""
+			if b.Git != nil {
+				author = b.Git.Author
+			}
+			rows = append(rows, []string{expiresWithTime, b.Owner, author, b.Ticket, location})
+		} else {
+			rows = append(rows, []string{expiresWithTime, b.Owner, b.Ticket, location})
 		}
-		loc := fmt.Sprintf("%s:%d", b.File, b.Line)
-		if len(loc) > wLocation {
-			wLocation = len(loc)
+	}
+
+	// Calculate max widths
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = len(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
 		}
 	}
 
 	// Helper to print separator
 	printSeparator := func() {
-		fmt.Printf("+-%s-+-%s-+-%s-+-%s-+\n",
-			strings.Repeat("-", wExpires),
-			strings.Repeat("-", wOwner),
-			strings.Repeat("-", wTicket),
-			strings.Repeat("-", wLocation),
-		)
+		for _, width := range widths {
+			fmt.Fprintf(w, "+-%s-", strings.Repeat("-", width))
+		}
+		fmt.Fprintln(w, "+")
+	}
+	printRow := func(cells []string) {
+		for i, cell := range cells {
+			fmt.Fprintf(w, "| %-*s ", widths[i], cell)
+		}
+		fmt.Fprintln(w, "|")
 	}
 
 	printSeparator()
-	fmt.Printf("| %-*s | %-*s | %-*s | %-*s |\n",
-		wExpires, hExpires,
-		wOwner, hOwner,
-		wTicket, hTicket,
-		wLocation, hLocation,
-	)
+	printRow(headers)
 	printSeparator()
-
-	for _, b := range bombs {
-		expiresWithTime := fmt.Sprintf("%s %s", b.Expire.Format("2006-01-02"), timeLeft(b.Expire))
-		fmt.Printf("| %-*s | %-*s | %-*s | %-*s |\n",
-			wExpires, expiresWithTime,
-			wOwner, b.Owner,
-			wTicket, b.Ticket,
-			wLocation, fmt.Sprintf("%s:%d", b.File, b.Line),
-		)
+	for _, row := range rows {
+		printRow(row)
 	}
 	printSeparator()
 }
 
 // PrintCheckReport prints the failure report for the check command
-func PrintCheckReport(expiredBombs []model.DebtBomb, warningBombs []model.DebtBomb, warnDays int) {
+func PrintCheckReport(expiredBombs []model.DebtBomb, warningBombs []model.DebtBomb, warnDays int, diagnostics []model.Diagnostic) {
+	WriteCheckReport(os.Stdout, expiredBombs, warningBombs, warnDays, diagnostics)
+}
+
+// WriteCheckReport writes the failure report for the check command
+func WriteCheckReport(w io.Writer, expiredBombs []model.DebtBomb, warningBombs []model.DebtBomb, warnDays int, diagnostics []model.Diagnostic) {
+	printed := false
 	if len(expiredBombs) > 0 {
-		fmt.Printf("DebtBomb exploded: %d expired\n\n", len(expiredBombs))
-		printBombList(expiredBombs)
+		fmt.Fprintf(w, "DebtBomb exploded: %d expired\n\n", len(expiredBombs))
+		printBombList(w, expiredBombs)
+		printed = true
 	}
 
 	if len(warningBombs) > 0 {
-		if len(expiredBombs) > 0 {
-			fmt.Println("\n")
+		if printed {
+			fmt.Fprint(w, "\n\n")
+		}
+		fmt.Fprintf(w, "DebtBomb warning: %d expiring within %d days\n\n", len(warningBombs), warnDays)
+		printBombList(w, warningBombs)
+		printed = true
+	}
+
+	if len(diagnostics) > 0 {
+		if printed {
+			fmt.Fprint(w, "\n\n")
+		}
+		fmt.Fprintf(w, "DebtBomb malformed: %d could not be parsed\n\n", len(diagnostics))
+		WriteDiagnostics(w, diagnostics)
+	}
+}
+
+// PrintDiagnostics prints one entry per malformed debtbomb
+func PrintDiagnostics(diagnostics []model.Diagnostic) {
+	WriteDiagnostics(os.Stdout, diagnostics)
+}
+
+// WriteDiagnostics writes one entry per malformed debtbomb
+func WriteDiagnostics(w io.Writer, diagnostics []model.Diagnostic) {
+	for i, d := range diagnostics {
+		fmt.Fprintf(w, "%s:%d: %s\n", d.Location(), d.Column, d.Problem)
+		fmt.Fprintf(w, "    %s\n", d.RawText)
+
+		if i < len(diagnostics)-1 {
+			fmt.Fprintln(w, "")
+		}
+	}
+}
+
+// PrintSkipped prints the files that could not be scanned to stderr
+func PrintSkipped(skipped []model.SkippedFile) {
+	WriteSkipped(os.Stderr, skipped)
+}
+
+// WriteSkipped writes the files that could not be scanned
+func WriteSkipped(w io.Writer, skipped []model.SkippedFile) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Skipped %d files:\n", len(skipped))
+	for _, s := range skipped {
+		if s.Detail != "" {
+			fmt.Fprintf(w, "  %s: %s (%s)\n", s.File, s.Reason, s.Detail)
+		} else {
+			fmt.Fprintf(w, "  %s: %s\n", s.File, s.Reason)
 		}
-		fmt.Printf("DebtBomb warning: %d expiring within %d days\n\n", len(warningBombs), warnDays)
-		printBombList(warningBombs)
 	}
 }
 
-func printBombList(bombs []model.DebtBomb) {
+// PrintDiagnosticsJSON prints the lint result in JSON format
+func PrintDiagnosticsJSON(diagnostics []model.Diagnostic) {
+	if err := WriteDiagnosticsJSON(os.Stdout, diagnostics); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode json: %v\n", err)
+	}
+}
+
+// WriteDiagnosticsJSON writes the lint result in JSON format
+func WriteDiagnosticsJSON(w io.Writer, diagnostics []model.Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []model.Diagnostic{}
+	}
+	out := struct {
+		Diagnostics []model.Diagnostic `json:"diagnostics"`
+	}{diagnostics}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+func printBombList(w io.Writer, bombs []model.DebtBomb) {
 	for i, b := range bombs {
-		fmt.Printf("%s:%d\n", b.File, b.Line)
+		fmt.Fprintln(w, b.Location())
 		label := "Expires"
 		if b.IsExpired {
 			label = "Expired"
 		}
-		fmt.Printf("%s: %s\n", label, b.Expire.Format("2006-01-02"))
+		if expr := writtenExpire(b); expr != "" {
+			fmt.Fprintf(w, "%s: %s (%s)\n", label, b.Expire.Format("2006-01-02"), expr)
+		} else {
+			fmt.Fprintf(w, "%s: %s\n", label, b.Expire.Format("2006-01-02"))
+		}
 		if b.Owner != "" {
-			fmt.Printf("Owner: %s\n", b.Owner)
+			fmt.Fprintf(w, "Owner: %s\n", b.Owner)
 		}
 		if b.Ticket != "" {
-			fmt.Printf("Ticket: %s\n", b.Ticket)
+			fmt.Fprintf(w, "Ticket: %s\n", b.Ticket)
 		}
 		if b.Reason != "" {
-			fmt.Printf("Reason: %s\n", b.Reason)
+			fmt.Fprintf(w, "Reason: %s\n", b.Reason)
+		}
+		if b.Git != nil {
+			fmt.Fprintf(w, "Introduced: %s by %s <%s> in %s\n",
+				b.Git.Date.Format("2006-01-02"), b.Git.Author, b.Git.AuthorEmail, b.Git.ShortCommit())
+		}
+		keys := make([]string, 0, len(b.Attributes))
+		for key := range b.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s: %s\n", key, b.Attributes[key])
 		}
 
 		if i < len(bombs)-1 {
-			fmt.Println("")
+			fmt.Fprintln(w, "")
 		}
 	}
 }
 
-func timeLeft(deadline time.Time) string {
-	tLeft := time.Until(deadline)
+// writtenExpire returns the expire expression as written, or "" if it is a plain date
+func writtenExpire(b model.DebtBomb) string {
+	if b.ExpireExpr == b.Expire.Format("2006-01-02") {
+		return ""
+	}
+	return b.ExpireExpr
+}
+
+func timeLeft(deadline, now time.Time) string {
+	tLeft := deadline.Sub(now)
 
 	if tLeft <= 0 {
 		return "(expired)"
@@ -185,54 +335,75 @@ func timeLeft(deadline time.Time) string {
 }
 
 func PrintReportJSON(r report.Report) {
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(r); err != nil {
+	if err := WriteReportJSON(os.Stdout, r); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to encode json: %v\n", err)
 	}
 }
 
+// WriteReportJSON writes the report in JSON format
+func WriteReportJSON(w io.Writer, r report.Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
 func PrintReport(r report.Report) {
-	printSection("Debt by owner", r.ByOwner, 5)
-	printSection("Debt by folder", r.ByFolder, 5)
-	printSection("Debt by reason", r.ByReason, 5)
-
-	fmt.Println("By urgency")
-	fmt.Printf("  %-15s %d\n", "Expired", r.ByUrgency.Expired)
-	fmt.Printf("  %-15s %d\n", "< 30 days", r.ByUrgency.Within30Days)
-	fmt.Printf("  %-15s %d\n", "< 90 days", r.ByUrgency.Within90Days)
-	fmt.Printf("  %-15s %d\n", "> 90 days", r.ByUrgency.MoreThan90Days)
-	fmt.Println()
-
-	fmt.Println("Extremes")
+	WriteReport(os.Stdout, r)
+}
+
+// WriteReport writes the report as text. The time left is measured
+// against r.GeneratedAt.
+func WriteReport(w io.Writer, r report.Report) {
+	printSection(w, "Debt by owner", r.ByOwner, 5)
+	printSection(w, "Debt by folder", r.ByFolder, 5)
+	printSection(w, "Debt by reason", r.ByReason, 5)
+	if len(r.ByAuthor) > 0 {
+		printSection(w, "Debt by author", r.ByAuthor, 5)
+	}
+
+	keys := make([]string, 0, len(r.ByAttribute))
+	for key := range r.ByAttribute {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		printSection(w, "Debt by "+key, r.ByAttribute[key], 5)
+	}
+
+	fmt.Fprintln(w, "By urgency")
+	fmt.Fprintf(w, "  %-15s %d\n", "Expired", r.ByUrgency.Expired)
+	fmt.Fprintf(w, "  %-15s %d\n", "< 30 days", r.ByUrgency.Within30Days)
+	fmt.Fprintf(w, "  %-15s %d\n", "< 90 days", r.ByUrgency.Within90Days)
+	fmt.Fprintf(w, "  %-15s %d\n", "> 90 days", r.ByUrgency.MoreThan90Days)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Extremes")
 	if r.Oldest != nil {
-		fmt.Printf("  Oldest: %s (%s) in %s:%d\n",
+		fmt.Fprintf(w, "  Oldest: %s (%s) in %s\n",
 			r.Oldest.Expire.Format("2006-01-02"),
-			timeLeft(r.Oldest.Expire),
-			r.Oldest.File,
-			r.Oldest.Line)
+			timeLeft(r.Oldest.Deadline(), r.GeneratedAt),
+			r.Oldest.Location())
 	}
 	if r.Newest != nil {
-		fmt.Printf("  Newest: %s (%s) in %s:%d\n",
+		fmt.Fprintf(w, "  Newest: %s (%s) in %s\n",
 			r.Newest.Expire.Format("2006-01-02"),
-			timeLeft(r.Newest.Expire),
-			r.Newest.File,
-			r.Newest.Line)
+			timeLeft(r.Newest.Deadline(), r.GeneratedAt),
+			r.Newest.Location())
 	}
 }
 
-func printSection(title string, items []report.CountItem, limit int) {
-	fmt.Println(title)
+func printSection(w io.Writer, title string, items []report.CountItem, limit int) {
+	fmt.Fprintln(w, title)
 	count := 0
 	for _, item := range items {
 		if count >= limit {
 			break
 		}
-		fmt.Printf("  %-20s %d\n", item.Key, item.Count)
+		fmt.Fprintf(w, "  %-20s %d\n", item.Key, item.Count)
 		count++
 	}
 	if len(items) > limit {
-		fmt.Printf("  ... and %d more\n", len(items)-limit)
+		fmt.Fprintf(w, "  ... and %d more\n", len(items)-limit)
 	}
-	fmt.Println()
-}
\ No newline at end of file
+	fmt.Fprintln(w)
+}
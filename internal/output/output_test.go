@@ -0,0 +1,75 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jobin-404/debtbomb/internal/clock"
+	"github.com/jobin-404/debtbomb/internal/model"
+	"github.com/jobin-404/debtbomb/internal/report"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestWriteTableWithFixedClock(t *testing.T) {
+	bombs := []model.DebtBomb{
+		{File: "a.go", Line: 3, Expire: day("2026-09-30"), Owner: "payments", IsExpired: true},
+		{File: "pkg/b.go", Line: 12, Expire: day("2026-10-03"), Ticket: "ENG-1"},
+	}
+
+	var buf bytes.Buffer
+	WriteTable(&buf, bombs, clock.Fixed(day("2026-10-01")))
+
+	want := `Found 2 DebtBombs
++----------------------+----------+--------+-------------+
+| Expires              | Owner    | Ticket | Location    |
++----------------------+----------+--------+-------------+
+| 2026-09-30 (expired) | payments |        | a.go:3      |
+| 2026-10-03 (3d0h)    |          | ENG-1  | pkg/b.go:12 |
++----------------------+----------+--------+-------------+
+`
+	if buf.String() != want {
+		t.Errorf("Unexpected table:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteReportUsesGeneratedAt(t *testing.T) {
+	bombs := []model.DebtBomb{
+		{File: "a.go", Line: 3, Expire: day("2026-10-11"), Owner: "payments"},
+	}
+	r := report.Generate(bombs, clock.Fixed(day("2026-10-01")))
+
+	if r.ByUrgency.Within30Days != 1 {
+		t.Errorf("Expected 1 bomb within 30 days, got %+v", r.ByUrgency)
+	}
+
+	var buf bytes.Buffer
+	WriteReport(&buf, r)
+	// The bomb is valid through 2026-10-11 and explodes at the start of the 12th
+	if !bytes.Contains(buf.Bytes(), []byte("(11d0h)")) {
+		t.Errorf("Expected time left measured from 2026-10-01, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteNDJSONBomb(t *testing.T) {
+	var buf bytes.Buffer
+	for _, b := range []model.DebtBomb{
+		{File: "a.go", Line: 3, Expire: day("2026-09-30"), Owner: "payments", IsExpired: true},
+		{File: "b.ipynb", Line: 1, Cell: 2, Expire: day("2026-10-03")},
+	} {
+		if err := WriteNDJSONBomb(&buf, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `{"file":"a.go","line":3,"expire":"2026-09-30","owner":"payments","expired":true}
+{"file":"b.ipynb","line":1,"cell":2,"expire":"2026-10-03","expired":false}
+`
+	if buf.String() != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
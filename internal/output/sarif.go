@@ -0,0 +1,277 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jobin-404/debtbomb/internal/identity"
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+// SARIF rule IDs
+const (
+	RuleExpired      = "expired"
+	RuleExpiringSoon = "expiring-soon"
+	RuleMalformed    = "malformed"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// fingerprintKey names the bomb ID in partialFingerprints; the version
+	// changes if the ID is ever computed differently
+	fingerprintKey = "debtbombId/v1"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	FullDescription      sarifMessage       `json:"fullDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+// sarifRules are listed in the order of the rule indexes below
+var sarifRules = []sarifRule{
+	{
+		ID:                   RuleExpired,
+		ShortDescription:     sarifMessage{"Debt bomb expired"},
+		FullDescription:      sarifMessage{"The expire date of a @debtbomb marker has passed. Pay off the debt or move the date."},
+		DefaultConfiguration: sarifConfiguration{"error"},
+	},
+	{
+		ID:                   RuleExpiringSoon,
+		ShortDescription:     sarifMessage{"Debt bomb expiring soon"},
+		FullDescription:      sarifMessage{"A @debtbomb marker expires within the warning window."},
+		DefaultConfiguration: sarifConfiguration{"warning"},
+	},
+	{
+		ID:                   RuleMalformed,
+		ShortDescription:     sarifMessage{"Malformed debt bomb"},
+		FullDescription:      sarifMessage{"A @debtbomb marker could not be parsed, so its expiry is not enforced."},
+		DefaultConfiguration: sarifConfiguration{"error"},
+	},
+}
+
+const (
+	ruleIndexExpired = iota
+	ruleIndexExpiringSoon
+	ruleIndexMalformed
+)
+
+// SARIFOptions controls WriteSARIF
+type SARIFOptions struct {
+	// WarnDays is the warning window the warning bombs were picked with
+	WarnDays int
+	// AllowMalformed reports malformed markers as warnings instead of
+	// errors, matching check --allow-malformed
+	AllowMalformed bool
+	// Path maps a reported file to its path from the repository root,
+	// which code scanning takes as %SRCROOT%; nil keeps the reported path
+	Path func(string) string
+}
+
+// PrintSARIF prints the check result as a SARIF 2.1.0 log
+func PrintSARIF(expiredBombs []model.DebtBomb, warningBombs []model.DebtBomb, diagnostics []model.Diagnostic, opts SARIFOptions) {
+	if err := WriteSARIF(os.Stdout, expiredBombs, warningBombs, diagnostics, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode sarif: %v\n", err)
+	}
+}
+
+// WriteSARIF writes the check result as a SARIF 2.1.0 log with one result
+// per expired or expiring bomb and per malformed marker. Results are
+// fingerprinted by bomb ID, so an alert keeps its identity across runs
+// while the marker moves within its file.
+func WriteSARIF(w io.Writer, expiredBombs []model.DebtBomb, warningBombs []model.DebtBomb, diagnostics []model.Diagnostic, opts SARIFOptions) error {
+	path := opts.Path
+	if path == nil {
+		path = func(file string) string { return file }
+	}
+	results := make([]sarifResult, 0, len(expiredBombs)+len(warningBombs)+len(diagnostics))
+	for _, b := range expiredBombs {
+		text := fmt.Sprintf("Debt bomb expired on %s", b.Expire.Format("2006-01-02"))
+		results = append(results, bombResult(b, path(b.File), ruleIndexExpired, text))
+	}
+	for _, b := range warningBombs {
+		text := fmt.Sprintf("Debt bomb expires on %s, within %d days", b.Expire.Format("2006-01-02"), opts.WarnDays)
+		results = append(results, bombResult(b, path(b.File), ruleIndexExpiringSoon, text))
+	}
+	level := sarifRules[ruleIndexMalformed].DefaultConfiguration.Level
+	if opts.AllowMalformed {
+		level = "warning"
+	}
+	for _, d := range diagnostics {
+		results = append(results, sarifResult{
+			RuleID:    RuleMalformed,
+			RuleIndex: ruleIndexMalformed,
+			Level:     level,
+			Message:   sarifMessage{"Malformed debt bomb: " + d.Problem},
+			Locations: []sarifLocation{sarifLocationOf(path(d.File), d.Cell, d.Line, d.Column, d.RawText)},
+			// Diagnostics have no ID; the problem and marker text stand in
+			PartialFingerprints: map[string]string{fingerprintKey: identity.ID(d.File, d.Problem, d.RawText)},
+			Properties:          cellProperties(d.Cell, d.Line),
+		})
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "debtbomb",
+				InformationURI: "https://github.com/jobin-404/debtbomb",
+				Rules:          sarifRules,
+			}},
+			Results: results,
+		}},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// bombResult reports b at file, its path as given to code scanning
+func bombResult(b model.DebtBomb, file string, ruleIndex int, text string) sarifResult {
+	rule := sarifRules[ruleIndex]
+	if b.Owner != "" {
+		text += fmt.Sprintf(" (owner: %s)", b.Owner)
+	}
+	if b.Reason != "" {
+		text += ": " + b.Reason
+	}
+
+	properties := cellProperties(b.Cell, b.Line)
+	for key, value := range map[string]string{"owner": b.Owner, "ticket": b.Ticket, "severity": b.Severity} {
+		if value != "" {
+			if properties == nil {
+				properties = make(map[string]any)
+			}
+			properties[key] = value
+		}
+	}
+
+	return sarifResult{
+		RuleID:              rule.ID,
+		RuleIndex:           ruleIndex,
+		Level:               sarifLevel(b.Severity, rule.DefaultConfiguration.Level),
+		Message:             sarifMessage{text},
+		Locations:           []sarifLocation{sarifLocationOf(file, b.Cell, b.Line, b.Column, b.RawText)},
+		PartialFingerprints: map[string]string{fingerprintKey: b.ID},
+		Properties:          properties,
+	}
+}
+
+// sarifLevel maps the severity attribute to a SARIF level. Jira priority
+// names and common severity words are understood; anything else keeps the
+// level of the rule.
+func sarifLevel(severity, ruleLevel string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "blocker", "critical", "highest", "high", "major", "error":
+		return "error"
+	case "medium", "moderate", "normal", "warning":
+		return "warning"
+	case "low", "lowest", "minor", "trivial", "info", "note":
+		return "note"
+	}
+	return ruleLevel
+}
+
+// sarifLocationOf points at the marker. Lines inside a notebook cell are
+// not lines of the file, so only the file is given for those.
+func sarifLocationOf(file string, cell, line, column int, rawText string) sarifLocation {
+	loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact(file)}}
+	if cell == 0 {
+		region := &sarifRegion{StartLine: line, StartColumn: column}
+		if rawText != "" {
+			region.Snippet = &sarifMessage{rawText}
+		}
+		loc.PhysicalLocation.Region = region
+	}
+	return loc
+}
+
+// sarifArtifact turns a reported path into a URI. Relative paths are
+// relative to the source root, which consumers know as %SRCROOT%.
+func sarifArtifact(file string) sarifArtifactLocation {
+	if filepath.IsAbs(file) {
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(file)}
+		if !strings.HasPrefix(u.Path, "/") {
+			// Windows drive letters
+			u.Path = "/" + u.Path
+		}
+		return sarifArtifactLocation{URI: u.String()}
+	}
+	u := url.URL{Path: filepath.ToSlash(file)}
+	return sarifArtifactLocation{URI: u.String(), URIBaseID: "%SRCROOT%"}
+}
+
+func cellProperties(cell, line int) map[string]any {
+	if cell == 0 {
+		return nil
+	}
+	return map[string]any{"cell": cell, "cellLine": line}
+}
@@ -0,0 +1,100 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	expired := []model.DebtBomb{
+		{ID: "id-1", File: "src/a b.go", Line: 3, Column: 4, Expire: day("2026-09-30"), Owner: "payments", RawText: "// @debtbomb(expire=2026-09-30)"},
+		{ID: "id-2", File: "b.go", Line: 1, Column: 1, Expire: day("2026-09-30"), Severity: "Low"},
+	}
+	warning := []model.DebtBomb{
+		{ID: "id-3", File: "nb.ipynb", Cell: 2, Line: 1, Column: 3, Expire: day("2026-10-03")},
+	}
+	diagnostics := []model.Diagnostic{
+		{File: "c.go", Line: 7, Column: 2, Problem: "missing expire date", RawText: "// @debtbomb(owner=x)"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, expired, warning, diagnostics, SARIFOptions{WarnDays: 7}); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected log: %+v", log)
+	}
+
+	results := log.Runs[0].Results
+	want := []struct {
+		rule, level, fingerprint string
+	}{
+		{RuleExpired, "error", "id-1"},
+		// severity=low lowers the level
+		{RuleExpired, "note", "id-2"},
+		{RuleExpiringSoon, "warning", "id-3"},
+		{RuleMalformed, "error", ""},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(results))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.RuleID != w.rule || r.Level != w.level || log.Runs[0].Tool.Driver.Rules[r.RuleIndex].ID != w.rule {
+			t.Errorf("Result %d: expected %s at level %s, got %+v", i, w.rule, w.level, r)
+		}
+		if fp := r.PartialFingerprints[fingerprintKey]; fp == "" || (w.fingerprint != "" && fp != w.fingerprint) {
+			t.Errorf("Result %d: expected fingerprint %q, got %q", i, w.fingerprint, fp)
+		}
+	}
+
+	loc := results[0].Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "src/a%20b.go" || loc.ArtifactLocation.URIBaseID != "%SRCROOT%" {
+		t.Errorf("Unexpected artifact location %+v", loc.ArtifactLocation)
+	}
+	if loc.Region == nil || loc.Region.StartLine != 3 || loc.Region.StartColumn != 4 {
+		t.Errorf("Unexpected region %+v", loc.Region)
+	}
+	// Cell lines are not file lines
+	if region := results[2].Locations[0].PhysicalLocation.Region; region != nil {
+		t.Errorf("Expected no region inside a notebook cell, got %+v", region)
+	}
+}
+
+func TestWriteSARIFOptions(t *testing.T) {
+	expired := []model.DebtBomb{{ID: "id-1", File: "a.go", Line: 1, Expire: day("2026-09-30")}}
+	diagnostics := []model.Diagnostic{{File: "c.go", Line: 7, Problem: "missing expire date"}}
+
+	var buf bytes.Buffer
+	opts := SARIFOptions{
+		AllowMalformed: true,
+		Path:           func(file string) string { return "services/billing/" + file },
+	}
+	if err := WriteSARIF(&buf, expired, nil, diagnostics, opts); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[1].Level != "warning" {
+		t.Errorf("Expected a malformed marker to be a warning with AllowMalformed, got %s", results[1].Level)
+	}
+	for i, want := range []string{"services/billing/a.go", "services/billing/c.go"} {
+		if uri := results[i].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != want {
+			t.Errorf("Result %d: expected URI %s, got %s", i, want, uri)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// attribute is a single key/value pair written on a marker
+type attribute struct {
+	key   string
+	value string
+}
+
+// Pattern for a known key anywhere in a "key: value" segment
+var kvKeyRegex = regexp.MustCompile(`(?:^|\s)(expire|owner|ticket|reason|severity)\s*:\s*`)
+
+// Pattern for a custom key at the start of a "key: value" segment. Custom
+// keys are lower case so that prose such as "TODO: fix" is not mistaken
+// for an attribute.
+var customKeyRegex = regexp.MustCompile(`^\s*([a-z][a-z0-9_.-]*)\s*:\s*`)
+
+// Pattern for an attribute on its own comment line: expire: 2026-01-01
+var attrLineRegex = regexp.MustCompile(`^([a-z][a-z0-9_.-]*)\s*[:=]\s*(.*)$`)
+
+// Tokens that separate "key: value" pairs written on one line after a bare
+// marker, e.g. the middle // in "expire: 2026-01-01 // owner: payments"
+var segmentSeparators = []string{"//", "/*", "*/", "#", "--", "*"}
+
+// parseAttributeList parses the parenthesized form. s starts right after
+// the opening parenthesis:
+//
+//	expire=2026-01-01, owner: payments, reason="retry loop, remove after v2"
+//
+// Values may be bare or quoted with ' or ". Quoted values support
+// backslash escapes; bare values end at the next , or ).
+func parseAttributeList(s string) ([]attribute, []string) {
+	var attrs []attribute
+	var problems []string
+
+	i := 0
+	for {
+		i = skipSpaces(s, i)
+		if i >= len(s) {
+			return attrs, append(problems, "unterminated attribute list")
+		}
+		if s[i] == ')' {
+			return attrs, problems
+		}
+
+		start := i
+		for i < len(s) && !strings.ContainsRune("=:,)", rune(s[i])) {
+			i++
+		}
+		key := strings.TrimSpace(s[start:i])
+		if i >= len(s) {
+			return attrs, append(problems, "unterminated attribute list")
+		}
+
+		if s[i] == ',' || s[i] == ')' {
+			if key != "" {
+				problems = append(problems, fmt.Sprintf("malformed attribute %q", key))
+			}
+			if s[i] == ')' {
+				return attrs, problems
+			}
+			i++
+			continue
+		}
+
+		// Skip the = or : separator
+		i = skipSpaces(s, i+1)
+
+		var value string
+		if i < len(s) && isQuote(s[i]) {
+			v, end, ok := readQuoted(s, i)
+			if !ok {
+				return attrs, append(problems, fmt.Sprintf("unterminated quoted value for %q", key))
+			}
+			value = v
+			i = skipSpaces(s, end)
+			if i < len(s) && s[i] != ',' && s[i] != ')' {
+				problems = append(problems, fmt.Sprintf("unexpected text after quoted value for %q", key))
+				for i < len(s) && s[i] != ',' && s[i] != ')' {
+					i++
+				}
+			}
+		} else {
+			start := i
+			for i < len(s) && s[i] != ',' && s[i] != ')' {
+				i++
+			}
+			value = strings.TrimSpace(s[start:i])
+		}
+		attrs = append(attrs, attribute{key: key, value: value})
+
+		if i >= len(s) {
+			return attrs, append(problems, "unterminated attribute list")
+		}
+		if s[i] == ')' {
+			return attrs, problems
+		}
+		i++
+	}
+}
+
+// parseKeyValueList parses "key: value" pairs written after a bare marker.
+// Pairs are separated by comment tokens surrounded by whitespace, so values
+// may contain URLs or "#1234" references.
+func parseKeyValueList(s string) ([]attribute, []string) {
+	var attrs []attribute
+	var problems []string
+
+	for _, segment := range splitSegments(s) {
+		loc := customKeyRegex.FindStringSubmatchIndex(segment)
+		if loc == nil {
+			loc = kvKeyRegex.FindStringSubmatchIndex(segment)
+		}
+		if loc == nil {
+			continue
+		}
+		key := segment[loc[2]:loc[3]]
+		value, err := parseValue(segment[loc[1]:])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%v for %q", err, key))
+			continue
+		}
+		attrs = append(attrs, attribute{key: key, value: value})
+	}
+
+	return attrs, problems
+}
+
+// parseAttributeLine parses an attribute written on its own comment line
+func parseAttributeLine(text string) (attribute, bool, error) {
+	matches := attrLineRegex.FindStringSubmatch(text)
+	if matches == nil {
+		return attribute{}, false, nil
+	}
+	value, err := parseValue(matches[2])
+	if err != nil {
+		return attribute{key: matches[1]}, true, fmt.Errorf("%v for %q", err, matches[1])
+	}
+	return attribute{key: matches[1], value: value}, true, nil
+}
+
+// parseValue returns a bare value trimmed, or a quoted value unquoted
+func parseValue(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || !isQuote(raw[0]) {
+		return raw, nil
+	}
+	value, _, ok := readQuoted(raw, 0)
+	if !ok {
+		return "", fmt.Errorf("unterminated quoted value")
+	}
+	return value, nil
+}
+
+// splitSegments splits s at separator tokens that stand on their own,
+// skipping over quoted values
+func splitSegments(s string) []string {
+	var segments []string
+	start := 0
+	i := 0
+	for i < len(s) {
+		if isQuote(s[i]) && valueStart(s, i) {
+			if _, end, ok := readQuoted(s, i); ok {
+				i = end
+				continue
+			}
+		}
+		if i == 0 || s[i-1] == ' ' || s[i-1] == '\t' {
+			if sep := separatorAt(s, i); sep != "" {
+				segments = append(segments, s[start:i])
+				i += len(sep)
+				start = i
+				continue
+			}
+		}
+		i++
+	}
+	return append(segments, s[start:])
+}
+
+func separatorAt(s string, i int) string {
+	for _, sep := range segmentSeparators {
+		end := i + len(sep)
+		if strings.HasPrefix(s[i:], sep) && (end == len(s) || s[end] == ' ' || s[end] == '\t') {
+			return sep
+		}
+	}
+	return ""
+}
+
+// valueStart reports whether the quote at i opens a value, i.e. follows "key:"
+func valueStart(s string, i int) bool {
+	j := i - 1
+	for j >= 0 && (s[j] == ' ' || s[j] == '\t') {
+		j--
+	}
+	return j >= 0 && (s[j] == ':' || s[j] == '=')
+}
+
+// readQuoted reads the quoted string starting at s[i] and returns its
+// unescaped value and the index just past the closing quote
+func readQuoted(s string, i int) (string, int, bool) {
+	quote := s[i]
+	var b strings.Builder
+	for j := i + 1; j < len(s); j++ {
+		c := s[j]
+		if c == '\\' && j+1 < len(s) {
+			j++
+			switch s[j] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[j])
+			}
+			continue
+		}
+		if c == quote {
+			return b.String(), j + 1, true
+		}
+		b.WriteByte(c)
+	}
+	return "", len(s), false
+}
+
+func isQuote(c byte) bool {
+	return c == '"' || c == '\''
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Relative expiry: +90d, +6w, +3m, +1y
+var relativeExpireRegex = regexp.MustCompile(`^\+(\d+)([dwmy])$`)
+
+// End of a quarter: 2026-Q3
+var quarterExpireRegex = regexp.MustCompile(`^(\d{4})-[Qq]([1-4])$`)
+
+// End of a month: 2026-09
+var monthExpireRegex = regexp.MustCompile(`^(\d{4})-(\d{2})$`)
+
+// IsRelativeExpire reports whether expr is relative to the date the bomb was introduced
+func IsRelativeExpire(expr string) bool {
+	return relativeExpireRegex.MatchString(expr)
+}
+
+// ParseExpire resolves an absolute expire expression:
+//
+//	2026-09-30                 that day
+//	2026-09                    last day of the month
+//	2026-Q3                    last day of the quarter
+//	2026-09-30T18:00:00+02:00  an RFC 3339 timestamp
+//
+// Relative expressions such as +90d need a base date; see ResolveExpire.
+func ParseExpire(expr string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", expr); err == nil {
+		return t, nil
+	}
+	if m := monthExpireRegex.FindStringSubmatch(expr); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		if month >= 1 && month <= 12 {
+			return lastDayOfMonth(year, month), nil
+		}
+	}
+	if m := quarterExpireRegex.FindStringSubmatch(expr); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		quarter, _ := strconv.Atoi(m[2])
+		return lastDayOfMonth(year, quarter*3), nil
+	}
+	if t, err := time.Parse(time.RFC3339, expr); err == nil {
+		return t, nil
+	}
+	if IsRelativeExpire(expr) {
+		return time.Time{}, fmt.Errorf("relative expire date %q needs a base date", expr)
+	}
+	return time.Time{}, fmt.Errorf("invalid expire date %q", expr)
+}
+
+// ResolveExpire resolves any expire expression. Relative expressions are
+// counted from the day of base.
+func ResolveExpire(expr string, base time.Time) (time.Time, error) {
+	m := relativeExpireRegex.FindStringSubmatch(expr)
+	if m == nil {
+		return ParseExpire(expr)
+	}
+
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expire date %q", expr)
+	}
+
+	day := time.Date(base.Year(), base.Month(), base.Day(), 0, 0, 0, 0, time.UTC)
+	switch m[2] {
+	case "d":
+		return day.AddDate(0, 0, n), nil
+	case "w":
+		return day.AddDate(0, 0, 7*n), nil
+	case "m":
+		return day.AddDate(0, n, 0), nil
+	default:
+		return day.AddDate(n, 0, 0), nil
+	}
+}
+
+func lastDayOfMonth(year, month int) time.Time {
+	// Day 0 of the next month is the last day of this one
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC)
+}
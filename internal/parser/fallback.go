@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jobin-404/debtbomb/internal/lexer"
+)
+
+var singleLineRegex = regexp.MustCompile(`(\/\/|#|--|\/\*)\s*@debtbomb\((.*?)\)`)
+
+// Pattern for a line that starts with a comment: // ... or a block comment continuation: * ...
+var commentStartRegex = regexp.MustCompile(`^\s*(\/\/|#|--|\/\*|\*)`)
+
+var commentDelims = []string{"//", "#", "--", "/*"}
+
+// fallbackLexer guesses comments with regular expressions for files whose
+// extension has no known lexer.Language. It cannot tell strings from
+// comments, so it is only used when nothing better is available.
+type fallbackLexer struct {
+	line int
+}
+
+func (f *fallbackLexer) Next(text string) lexer.Line {
+	f.line++
+	line := lexer.Line{Number: f.line, Text: text}
+
+	idx := strings.Index(text, marker)
+	if idx == -1 {
+		if loc := commentStartRegex.FindStringSubmatchIndex(text); loc != nil {
+			line.Comments = []lexer.Comment{{Text: text[loc[3]:], Offset: loc[3]}}
+		} else {
+			line.Code = strings.TrimSpace(text)
+		}
+		return line
+	}
+
+	// Parenthesized form: the marker followed by an attribute list
+	if loc := singleLineRegex.FindStringSubmatchIndex(text); loc != nil {
+		line.Code = strings.TrimSpace(text[:loc[2]])
+		line.Comments = []lexer.Comment{{Text: text[loc[3]:], Offset: loc[3]}}
+		return line
+	}
+
+	if loc := commentStartRegex.FindStringSubmatchIndex(text); loc != nil {
+		line.Comments = []lexer.Comment{{Text: text[loc[3]:], Offset: loc[3]}}
+		return line
+	}
+
+	// Inline form: code followed by a comment holding the marker
+	pre := text[:idx]
+	commentIdx, delim := -1, ""
+	for _, c := range commentDelims {
+		if i := strings.LastIndex(pre, c); i > commentIdx {
+			commentIdx, delim = i, c
+		}
+	}
+	if commentIdx == -1 {
+		line.Comments = []lexer.Comment{{Text: text[idx:], Offset: idx}}
+		return line
+	}
+
+	start := commentIdx + len(delim)
+	line.Code = strings.TrimSpace(pre[:commentIdx])
+	line.Comments = []lexer.Comment{{Text: text[start:], Offset: start}}
+	return line
+}
@@ -2,236 +2,398 @@ package parser
 
 import (
 	"bufio"
-	"crypto/sha1"
 	"fmt"
 	"io"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/jobin-404/debtbomb/internal/format"
+	"github.com/jobin-404/debtbomb/internal/identity"
+	"github.com/jobin-404/debtbomb/internal/lexer"
 	"github.com/jobin-404/debtbomb/internal/model"
 )
 
-var singleLineRegex = regexp.MustCompile(`(?:\/\/|#|--|\/\*)\s*@debtbomb\((.*?)\)`)
+const marker = "@debtbomb"
 
-// Pattern for multi line start: // @debtbomb
-var multiLineStartRegex = regexp.MustCompile(`(?:\/\/|#|--|\/\*)\s*@debtbomb\s*$`)
+// Characters that decorate comment lines, e.g. the leading * in Javadoc blocks
+const commentDecoration = " \t*/!#-;%"
 
-// Regex to find key: value pairs where key is one of our expected fields
-var kvRegex = regexp.MustCompile(`(expire|owner|ticket|reason|severity)\s*:\s*([^\/\#\*]+)`)
+// lineLexer splits a physical line into code and comments
+type lineLexer interface {
+	Next(text string) lexer.Line
+}
+
+func newLineLexer(filename string) lineLexer {
+	if lang := lexer.ForFile(filename); lang != nil {
+		return lexer.New(lang)
+	}
+	return &fallbackLexer{}
+}
+
+// Version identifies the parser's output. Bump it whenever a change makes
+// the same input parse differently, so that cached results are discarded.
+const Version = "5"
 
-// Parse scans the content and returns a list of DebtBombs
+// Result holds what was found in a single file
+type Result struct {
+	Bombs       []model.DebtBomb
+	Diagnostics []model.Diagnostic
+}
+
+// Parser parses files with project settings. The zero value accepts only
+// the built-in keys on attribute lines.
+type Parser struct {
+	// Keys are the custom attribute keys accepted on the comment lines
+	// after a bare marker, e.g. those declared in the config. A line with
+	// any other key is prose and ends the attribute list. Custom keys on
+	// the marker line itself are always accepted.
+	Keys []string
+}
+
+// Parse scans the content and returns a list of DebtBombs. Malformed
+// markers are dropped; use ParseFile to get diagnostics for them.
 func Parse(filename string, reader io.Reader) ([]model.DebtBomb, error) {
-	var bombs []model.DebtBomb
-	var pendingBombs []*model.DebtBomb
+	result, err := ParseFile(filename, reader)
+	return result.Bombs, err
+}
+
+// ParseFile scans the content and returns the DebtBombs found in it along
+// with a diagnostic for every malformed marker. Only markers inside real
+// comments are reported; for languages without a known lexer comments are
+// detected heuristically.
+//
+// Bombs with a relative expire expression (+90d) are returned with a zero
+// Expire; the caller resolves them with ResolveExpire.
+//
+// A bare marker may be followed by "key: value" lines in the same comment
+// block. The snippet of a bomb is the first line of code after the block,
+// or the code on the marker line itself for inline bombs.
+func ParseFile(filename string, reader io.Reader) (Result, error) {
+	return Parser{}.ParseFile(filename, reader)
+}
+
+// ParseFile is the package-level ParseFile with the settings of ps
+func (ps Parser) ParseFile(filename string, reader io.Reader) (Result, error) {
+	p := ps.newState(filename)
+	err := p.parse(newLineLexer(filename), reader)
+	return Result{Bombs: p.bombs, Diagnostics: p.diagnostics}, err
+}
+
+// ParseSections is ParseFile for a file that a format adapter split into
+// sections, e.g. the cells of a notebook. Each section is lexed in its own
+// language and bombs in notebook cells carry the cell number.
+func ParseSections(filename string, sections []format.Section) (Result, error) {
+	return Parser{}.ParseSections(filename, sections)
+}
+
+// ParseSections is the package-level ParseSections with the settings of ps
+func (ps Parser) ParseSections(filename string, sections []format.Section) (Result, error) {
+	p := ps.newState(filename)
+	for _, section := range sections {
+		p.cell = section.Cell
+		if err := p.parse(newLineLexer(section.Lang), strings.NewReader(section.Text)); err != nil {
+			return Result{Bombs: p.bombs, Diagnostics: p.diagnostics}, err
+		}
+	}
+	return Result{Bombs: p.bombs, Diagnostics: p.diagnostics}, nil
+}
 
+func (ps Parser) newState(filename string) *parseState {
+	p := &parseState{filename: filename}
+	if len(ps.Keys) > 0 {
+		p.keys = make(map[string]bool, len(ps.Keys))
+		for _, key := range ps.Keys {
+			p.keys[key] = true
+		}
+	}
+	return p
+}
+
+// parse finds the bombs in one stream of lines
+func (p *parseState) parse(lex lineLexer, reader io.Reader) error {
 	scanner := bufio.NewScanner(reader)
-	lineNum := 0
 
 	for scanner.Scan() {
-		lineNum++
-		lineText := scanner.Text()
-		trimmedLine := strings.TrimSpace(lineText)
+		line := lex.Next(scanner.Text())
+		trimmedLine := strings.TrimSpace(line.Text)
 
 		if trimmedLine == "" {
+			p.blankLine()
 			continue
 		}
 
-		// Check if this line is a debtbomb
-		isBomb := false
-		var currentBombs []model.DebtBomb
-
-		// Check single line
-		if matches := singleLineRegex.FindStringSubmatch(lineText); len(matches) > 1 {
-			bomb, err := parseAttributes(matches[1])
-			if err == nil {
-				bomb.File = filename
-				bomb.Line = lineNum
-				bomb.RawText = trimmedLine
-				currentBombs = append(currentBombs, bomb)
-				isBomb = true
-
-				// Check for inline
-				loc := singleLineRegex.FindStringIndex(lineText)
-				if loc != nil && loc[0] > 0 {
-					// Check if there is code before
-					pre := strings.TrimSpace(lineText[:loc[0]])
-					if pre != "" {
-						// Inline bomb
-						for i := range currentBombs {
-							currentBombs[i].Snippet = pre
-							currentBombs[i].ID = generateID(filename, currentBombs[i].Reason, pre)
-							bombs = append(bombs, currentBombs[i])
-						}
-						// Clear currentBombs so they don't get added to pending
-						currentBombs = nil
-					}
-				}
-			}
-		} else if idx := strings.Index(lineText, "@debtbomb"); idx != -1 {
-			// Check if it was already handled by singleLineRegex (parentheses format)
-			if !singleLineRegex.MatchString(lineText) {
-				content := lineText[idx+len("@debtbomb"):]
-				bomb, err := parseKeyValueStyle(content)
-				if err == nil {
-					bomb.File = filename
-					bomb.Line = lineNum
-					bomb.RawText = trimmedLine
-					currentBombs = append(currentBombs, bomb)
-					isBomb = true
-
-					// Check for inline
-					commentStart := regexp.MustCompile(`^\s*(?:\/\/|#|--|\/\*)`)
-					if !commentStart.MatchString(lineText) {
-						pre := lineText[:idx]
-						commentIdx := -1
-						// This is naive, but might work for supported languages
-						for _, c := range []string{"//", "#", "--", "/*"} {
-							if i := strings.LastIndex(pre, c); i != -1 {
-								if commentIdx == -1 || i > commentIdx {
-									commentIdx = i
-								}
-							}
-						}
-
-						if commentIdx != -1 {
-							code := strings.TrimSpace(pre[:commentIdx])
-							if code != "" {
-								for i := range currentBombs {
-									currentBombs[i].Snippet = code
-									currentBombs[i].ID = generateID(filename, currentBombs[i].Reason, code)
-									bombs = append(bombs, currentBombs[i])
-								}
-								currentBombs = nil
-							}
-						}
-					}
-				}
-			}
+		found := p.markers(line, trimmedLine)
+		if len(found) == 0 && line.Code == "" {
+			// Comment-only line: attributes for the open bomb, or just prose
+			p.collectAttributes(line)
+			continue
 		}
 
-		if isBomb {
-			for i := range currentBombs {
-				// Copy to avoid pointer issues with loop var
-				b := currentBombs[i]
-				pendingBombs = append(pendingBombs, &b)
-			}
-		} else {
-			// Not a bomb line. If we have pending bombs, this line is the snippet.
-			if len(pendingBombs) > 0 {
-				snippet := trimmedLine
-				for _, b := range pendingBombs {
-					b.Snippet = snippet
-					b.ID = generateID(filename, b.Reason, snippet)
-					bombs = append(bombs, *b)
-				}
-				pendingBombs = nil
-			}
+		p.endAttributes()
+		p.pending = append(p.pending, found...)
+		if line.Code != "" {
+			p.setSnippet(line.Code)
 		}
+		p.flush()
 	}
 
 	// Flush pending
-	if len(pendingBombs) > 0 {
-		for _, b := range pendingBombs {
-			b.Snippet = "EOF"
-			b.ID = generateID(filename, b.Reason, "EOF")
-			bombs = append(bombs, *b)
+	p.endAttributes()
+	p.setSnippet(model.EOFSnippet)
+	p.flush()
+
+	return scanner.Err()
+}
+
+// pendingBomb is a bomb that is still waiting for attributes or a snippet
+type pendingBomb struct {
+	bomb       model.DebtBomb
+	collecting bool // gathering "key: value" lines from the following comment lines
+	block      bool // the marker is inside a block comment
+	hasSnippet bool
+	problems   []string
+}
+
+type parseState struct {
+	filename    string
+	keys        map[string]bool // custom keys accepted on attribute lines
+	cell        int
+	bombs       []model.DebtBomb
+	diagnostics []model.Diagnostic
+	pending     []*pendingBomb
+	// generated counts the bombs per generated ID, so that identical
+	// bombs in one file still get distinct IDs
+	generated map[string]int
+}
+
+// markers parses every @debtbomb marker found in the comments of a line
+func (p *parseState) markers(line lexer.Line, trimmedLine string) []*pendingBomb {
+	var found []*pendingBomb
+	for _, c := range line.Comments {
+		idx := markerIndex(c.Text)
+		if idx == -1 {
+			continue
 		}
+		bomb, open, problems := parseMarker(c.Text[idx+len(marker):])
+		bomb.File = p.filename
+		bomb.Line = line.Number
+		bomb.Cell = p.cell
+		bomb.Column = c.Offset + idx + 1
+		bomb.RawText = trimmedLine
+		found = append(found, &pendingBomb{bomb: bomb, collecting: open, block: c.Block, problems: problems})
 	}
+	return found
+}
 
-	return bombs, scanner.Err()
+// collectAttributes applies "key: value" comment lines to the bomb that is
+// currently gathering attributes. The first line that is not an attribute
+// with a built-in or declared key ends the attribute list.
+func (p *parseState) collectAttributes(line lexer.Line) {
+	cur := p.collecting()
+	if cur == nil {
+		return
+	}
+	for _, c := range line.Comments {
+		text := strings.TrimSpace(strings.TrimLeft(c.Text, commentDecoration))
+		if text == "" {
+			continue
+		}
+		attr, ok, err := parseAttributeLine(text)
+		if !ok || !(isBuiltinKey(attr.key) || p.keys[attr.key]) {
+			p.endAttributes()
+			return
+		}
+		if err == nil {
+			err = applyAttribute(&cur.bomb, attr.key, attr.value)
+		}
+		if err != nil {
+			cur.problems = append(cur.problems, err.Error())
+		}
+	}
 }
 
-func parseAttributes(attrString string) (model.DebtBomb, error) {
-	// Format: expire=2026-02-10, owner=pricing, ticket=JIRA-123
-	bomb := model.DebtBomb{}
-	parts := strings.Split(attrString, ",")
-	foundExpire := false
+// blankLine ends the attribute list of a bomb written with line comments.
+// Block comments may contain blank lines.
+func (p *parseState) blankLine() {
+	if cur := p.collecting(); cur != nil && !cur.block {
+		p.endAttributes()
+	}
+}
+
+func (p *parseState) collecting() *pendingBomb {
+	for _, pb := range p.pending {
+		if pb.collecting {
+			return pb
+		}
+	}
+	return nil
+}
+
+func (p *parseState) endAttributes() {
+	for _, pb := range p.pending {
+		pb.collecting = false
+	}
+}
 
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		var kv []string
-		if strings.Contains(part, "=") {
-			kv = strings.SplitN(part, "=", 2)
-		} else if strings.Contains(part, ":") {
-			kv = strings.SplitN(part, ":", 2)
+func (p *parseState) setSnippet(snippet string) {
+	for _, pb := range p.pending {
+		if !pb.hasSnippet {
+			pb.bomb.Snippet = snippet
+			if pb.bomb.ID == "" {
+				pb.bomb.ID = p.generateID(pb.bomb.Reason, snippet)
+			}
+			pb.hasSnippet = true
 		}
+	}
+}
 
-		if len(kv) != 2 {
+// generateID returns the ID of a bomb without an explicit one, numbering
+// repeats of the same reason and snippet in the order they appear
+func (p *parseState) generateID(reason, snippet string) string {
+	id := identity.ID(p.filename, reason, snippet)
+	if p.generated == nil {
+		p.generated = make(map[string]int)
+	}
+	p.generated[id]++
+	return identity.Occurrence(id, p.generated[id])
+}
+
+// flush emits the bombs that are complete. Every problem found while
+// parsing a marker becomes a diagnostic; bombs without a usable expire
+// date are dropped.
+func (p *parseState) flush() {
+	var rest []*pendingBomb
+	for _, pb := range p.pending {
+		if pb.collecting || !pb.hasSnippet {
+			rest = append(rest, pb)
 			continue
 		}
-		key := strings.TrimSpace(kv[0])
-		val := strings.TrimSpace(kv[1])
 
-		switch key {
-		case "expire":
-			t, err := time.Parse("2006-01-02", val)
-			if err == nil {
-				bomb.Expire = t
-				foundExpire = true
-			}
-		case "owner":
-			bomb.Owner = val
-		case "ticket":
-			bomb.Ticket = val
-		case "reason":
-			bomb.Reason = val
-		case "severity":
-			bomb.Severity = val
+		hasExpire := !pb.bomb.Expire.IsZero() || IsRelativeExpire(pb.bomb.ExpireExpr)
+		problems := pb.problems
+		if !hasExpire && len(problems) == 0 {
+			problems = append(problems, "missing expire date")
+		}
+		for _, problem := range problems {
+			p.diagnostics = append(p.diagnostics, model.Diagnostic{
+				File:    pb.bomb.File,
+				Line:    pb.bomb.Line,
+				Column:  pb.bomb.Column,
+				Cell:    pb.bomb.Cell,
+				Problem: problem,
+				RawText: pb.bomb.RawText,
+			})
 		}
 
+		if hasExpire {
+			p.bombs = append(p.bombs, pb.bomb)
+		}
 	}
+	p.pending = rest
+}
 
-	if !foundExpire {
-		return bomb, fmt.Errorf("missing expire date")
+// markerIndex returns the index of the first marker in a comment, or -1.
+// Prose that mentions @debtbomb is not a marker: the marker must be
+// followed by an attribute list, "key: value" pairs or the end of the
+// comment, where attribute lines may follow.
+func markerIndex(text string) int {
+	offset := 0
+	for {
+		idx := strings.Index(text[offset:], marker)
+		if idx == -1 {
+			return -1
+		}
+		idx += offset
+		if isMarker(text[idx+len(marker):]) {
+			return idx
+		}
+		offset = idx + len(marker)
 	}
-	return bomb, nil
 }
 
-func parseKeyValueStyle(content string) (model.DebtBomb, error) {
+func isMarker(rest string) bool {
+	trimmed := strings.Trim(rest, commentDecoration)
+	if trimmed == "" || strings.HasPrefix(strings.TrimLeft(rest, " \t"), "(") {
+		return true
+	}
+	if rest[0] != ' ' && rest[0] != '\t' {
+		// e.g. @debtbombs
+		return false
+	}
+	attrs, problems := parseKeyValueList(rest)
+	return len(attrs) > 0 || len(problems) > 0
+}
 
+// parseMarker parses the attributes that follow an @debtbomb marker and
+// reports whether more attributes may follow on the next comment lines
+func parseMarker(rest string) (model.DebtBomb, bool, []string) {
 	bomb := model.DebtBomb{}
-	foundExpire := false
 
-	matches := kvRegex.FindAllStringSubmatch(content, -1)
+	trimmed := strings.TrimLeft(rest, " \t")
+	if strings.HasPrefix(trimmed, "(") {
+		// Format: expire=2026-02-10, owner=pricing, ticket=JIRA-123
+		attrs, problems := parseAttributeList(trimmed[1:])
+		return bomb, false, applyAttributes(&bomb, attrs, problems)
+	}
 
-	for _, match := range matches {
-		key := match[1]
-		val := strings.TrimSpace(match[2])
+	attrs, problems := parseKeyValueList(rest)
+	return bomb, true, applyAttributes(&bomb, attrs, problems)
+}
 
-		switch key {
-		case "expire":
-			t, err := time.Parse("2006-01-02", val)
-			if err == nil {
-				bomb.Expire = t
-				foundExpire = true
-			}
-		case "owner":
-			bomb.Owner = val
-		case "ticket":
-			bomb.Ticket = val
-		case "reason":
-			bomb.Reason = val
-		case "severity":
-			bomb.Severity = val
+// applyAttributes sets the attributes on bomb and returns the problems found
+func applyAttributes(bomb *model.DebtBomb, attrs []attribute, problems []string) []string {
+	for _, a := range attrs {
+		if err := applyAttribute(bomb, a.key, a.value); err != nil {
+			problems = append(problems, err.Error())
 		}
-
 	}
+	return problems
+}
 
-	if !foundExpire {
-		return bomb, fmt.Errorf("missing expire date")
+// isBuiltinKey reports whether applyAttribute sets a field for key
+func isBuiltinKey(key string) bool {
+	switch key {
+	case "expire", "id", "owner", "ticket", "reason", "severity", "tz":
+		return true
 	}
-	return bomb, nil
+	return false
 }
 
-func generateID(file, reason, snippet string) string {
-	h := sha1.New()
-	h.Write([]byte(file))
-	h.Write([]byte(reason))
-	cleanSnippet := strings.TrimSpace(snippet)
-	if len(cleanSnippet) > 80 {
-		cleanSnippet = cleanSnippet[:80]
+func applyAttribute(bomb *model.DebtBomb, key, val string) error {
+	switch key {
+	case "expire":
+		bomb.ExpireExpr = val
+		bomb.Expire = time.Time{}
+		if IsRelativeExpire(val) {
+			// Resolved by the caller once the base date is known
+			return nil
+		}
+		t, err := ParseExpire(val)
+		if err != nil {
+			return err
+		}
+		bomb.Expire = t
+	case "id":
+		// An explicit ID survives moves and edits of the surrounding code
+		bomb.ID = val
+	case "owner":
+		bomb.Owner = val
+	case "ticket":
+		bomb.Ticket = val
+	case "reason":
+		bomb.Reason = val
+	case "severity":
+		bomb.Severity = val
+	case "tz":
+		if _, err := time.LoadLocation(val); err != nil || val == "" {
+			return fmt.Errorf("unknown time zone %q", val)
+		}
+		bomb.TimeZone = val
+	default:
+		if bomb.Attributes == nil {
+			bomb.Attributes = make(map[string]string)
+		}
+		bomb.Attributes[key] = val
 	}
-	h.Write([]byte(cleanSnippet))
-	return fmt.Sprintf("%x", h.Sum(nil))
+	return nil
 }
@@ -1,14 +1,18 @@
 package parser
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jobin-404/debtbomb/internal/format"
 )
 
 func TestParseSingleLine(t *testing.T) {
 	content := `
-	// @debtbomb(expire=2026-01-14, owner:jobin)
+	// @debtbomb(expire=2026-01-14, owner:test)
 	code()
 	`
 	bombs, err := Parse("test.go", strings.NewReader(content))
@@ -75,3 +79,468 @@ func TestParseMixedSyntax(t *testing.T) {
 		t.Errorf("Expected owner test-mixed, got %s", b.Owner)
 	}
 }
+
+func TestParseIgnoresMarkersInStrings(t *testing.T) {
+	content := `
+	msg := "// @debtbomb(expire=2020-01-01)"
+	url := "https://example.com/#@debtbomb(expire=2020-01-01)"
+	run() // @debtbomb(expire=2026-01-16, owner:strings)
+	`
+	bombs, err := Parse("test.go", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(bombs) != 1 {
+		t.Fatalf("Expected 1 bomb, got %d", len(bombs))
+	}
+
+	b := bombs[0]
+	if b.Owner != "strings" {
+		t.Errorf("Expected owner strings, got %s", b.Owner)
+	}
+	if b.Snippet != "run()" {
+		t.Errorf("Expected snippet run(), got %s", b.Snippet)
+	}
+}
+
+func TestParseLanguageComments(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{"query.sql", "SELECT '-- @debtbomb(expire=2020-01-01)';\n-- @debtbomb(expire=2026-01-17, owner:sql)\nSELECT 1;"},
+		{"script.py", "s = '# @debtbomb(expire=2020-01-01)'\n# @debtbomb(expire=2026-01-17, owner:py)\nrun()"},
+		{"page.html", "<p>@debtbomb(expire=2020-01-01)</p>\n<!-- @debtbomb(expire=2026-01-17, owner:html) -->\n<div></div>"},
+		{"main.hs", "s = \"-- @debtbomb(expire=2020-01-01)\"\n{- @debtbomb(expire=2026-01-17, owner:hs) -}\nmain = pure ()"},
+	}
+
+	for _, tt := range tests {
+		bombs, err := Parse(tt.file, strings.NewReader(tt.content))
+		if err != nil {
+			t.Fatalf("%s: Parse failed: %v", tt.file, err)
+		}
+		if len(bombs) != 1 {
+			t.Fatalf("%s: Expected 1 bomb, got %d", tt.file, len(bombs))
+		}
+		if bombs[0].Line != 2 {
+			t.Errorf("%s: Expected line 2, got %d", tt.file, bombs[0].Line)
+		}
+	}
+}
+
+func TestParseUnknownExtensionFallback(t *testing.T) {
+	content := `
+	run() # @debtbomb(expire=2026-01-18, owner:fallback)
+	`
+	bombs, err := Parse("notes.unknown", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(bombs) != 1 {
+		t.Fatalf("Expected 1 bomb, got %d", len(bombs))
+	}
+	if bombs[0].Snippet != "run()" {
+		t.Errorf("Expected snippet run(), got %s", bombs[0].Snippet)
+	}
+}
+
+func TestParseBlockCommentAttributes(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{"Billing.java", "/**\n * @debtbomb\n * expire: 2026-01-01\n * owner: payments\n */\nint retries = 3;"},
+		{"billing.go", "// @debtbomb\n//   expire: 2026-01-01\n//   owner: payments\n// Temporary surge override\nretries := 3"},
+		{"billing.py", "def f():\n    \"\"\"\n    @debtbomb\n    expire: 2026-01-01\n    owner: payments\n    \"\"\"\n    retries = 3"},
+		{"billing.unknown", "/*\n * @debtbomb\n * expire: 2026-01-01\n * owner: payments\n */\nretries = 3"},
+	}
+
+	expectedDate, _ := time.Parse("2006-01-02", "2026-01-01")
+	for _, tt := range tests {
+		bombs, err := Parse(tt.file, strings.NewReader(tt.content))
+		if err != nil {
+			t.Fatalf("%s: Parse failed: %v", tt.file, err)
+		}
+		if len(bombs) != 1 {
+			t.Fatalf("%s: Expected 1 bomb, got %d", tt.file, len(bombs))
+		}
+
+		b := bombs[0]
+		if !b.Expire.Equal(expectedDate) {
+			t.Errorf("%s: Expected date %v, got %v", tt.file, expectedDate, b.Expire)
+		}
+		if b.Owner != "payments" {
+			t.Errorf("%s: Expected owner payments, got %s", tt.file, b.Owner)
+		}
+		if !strings.Contains(b.Snippet, "retries") {
+			t.Errorf("%s: Expected snippet to be the first code line, got %s", tt.file, b.Snippet)
+		}
+	}
+}
+
+func TestParseBlankLineEndsLineCommentAttributes(t *testing.T) {
+	content := "// @debtbomb\n\n// expire: 2026-01-01\ncode()"
+	bombs, err := Parse("test.go", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(bombs) != 0 {
+		t.Fatalf("Expected 0 bombs, got %d", len(bombs))
+	}
+}
+
+func TestParseFileDiagnostics(t *testing.T) {
+	content := `
+	// @debtbomb(owner=nobody)
+	// @debtbomb(expire=2026-13-40, owner=typo)
+	// @debtbomb(expire=2026-01-01, oops)
+	code()
+	// @debtbomb(expire=2026-01-01, tz=Mars/Olympus)
+	code()
+	`
+	result, err := ParseFile("test.go", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Bombs with a valid expire date are kept despite other problems
+	if len(result.Bombs) != 2 {
+		t.Fatalf("Expected 2 bombs, got %d", len(result.Bombs))
+	}
+
+	expected := []struct {
+		line    int
+		problem string
+	}{
+		{2, "missing expire date"},
+		{3, `invalid expire date "2026-13-40"`},
+		{4, `malformed attribute "oops"`},
+		{6, `unknown time zone "Mars/Olympus"`},
+	}
+	if len(result.Diagnostics) != len(expected) {
+		t.Fatalf("Expected %d diagnostics, got %+v", len(expected), result.Diagnostics)
+	}
+	for i, e := range expected {
+		d := result.Diagnostics[i]
+		if d.Line != e.line || d.Problem != e.problem {
+			t.Errorf("Expected %d: %s, got %d: %s", e.line, e.problem, d.Line, d.Problem)
+		}
+		if d.Column != 5 {
+			t.Errorf("Expected column 5, got %d", d.Column)
+		}
+	}
+}
+
+func TestParseIgnoresProseMentioningMarker(t *testing.T) {
+	content := `
+	// TODO @debtbomb is our tool
+	// markers parses every @debtbomb marker found in a line
+	/* see the @debtbombs docs */
+	code()
+	// @debtbomb
+	//   expire: 2026-01-01
+	real()
+	`
+	result, err := ParseFile("test.go", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(result.Diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics for prose, got %+v", result.Diagnostics)
+	}
+	if len(result.Bombs) != 1 || result.Bombs[0].Snippet != "real()" {
+		t.Errorf("Expected only the real bomb, got %+v", result.Bombs)
+	}
+}
+
+func TestResolveExpire(t *testing.T) {
+	base := time.Date(2026, 1, 10, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"2026-02-10", time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)},
+		{"2026-09", time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)},
+		{"2024-02", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"2026-Q3", time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)},
+		{"2026-q4", time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"+90d", time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC)},
+		{"+2w", time.Date(2026, 1, 24, 0, 0, 0, 0, time.UTC)},
+		{"+1y", time.Date(2027, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{"2026-09-30T18:00:00+02:00", time.Date(2026, 9, 30, 16, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolveExpire(tt.expr, base)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("%s: Expected %v, got %v", tt.expr, tt.expected, got)
+		}
+	}
+
+	for _, expr := range []string{"2026-13", "2026-Q5", "+90", "tomorrow"} {
+		if _, err := ResolveExpire(expr, base); err == nil {
+			t.Errorf("%s: expected an error", expr)
+		}
+	}
+}
+
+func TestParseKeepsExpireExpression(t *testing.T) {
+	content := `
+	// @debtbomb(expire=2026-Q3, owner=quarter)
+	a()
+	// @debtbomb(expire=+90d, owner=relative)
+	b()
+	`
+	bombs, err := Parse("test.go", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(bombs) != 2 {
+		t.Fatalf("Expected 2 bombs, got %d", len(bombs))
+	}
+	if bombs[0].ExpireExpr != "2026-Q3" || bombs[0].Expire.Format("2006-01-02") != "2026-09-30" {
+		t.Errorf("Unexpected quarter expiry %s / %v", bombs[0].ExpireExpr, bombs[0].Expire)
+	}
+	if bombs[1].ExpireExpr != "+90d" || !bombs[1].Expire.IsZero() {
+		t.Errorf("Expected unresolved relative expiry, got %s / %v", bombs[1].ExpireExpr, bombs[1].Expire)
+	}
+}
+
+func TestParseAttributeGrammar(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		reason  string
+		ticket  string
+		problem string
+	}{
+		{
+			name:    "quoted comma",
+			content: `// @debtbomb(expire=2026-01-01, reason="retry loop, remove after v2 migration")`,
+			reason:  "retry loop, remove after v2 migration",
+		},
+		{
+			name:    "single quotes",
+			content: `// @debtbomb(expire=2026-01-01, reason='wait for "v2"')`,
+			reason:  `wait for "v2"`,
+		},
+		{
+			name:    "escapes",
+			content: `// @debtbomb(expire=2026-01-01, reason="say \"hi\", then \\ leave")`,
+			reason:  `say "hi", then \ leave`,
+		},
+		{
+			name:    "parenthesis inside quotes",
+			content: `// @debtbomb(expire=2026-01-01, reason="fix (later)", ticket=ABC-1)`,
+			reason:  "fix (later)",
+			ticket:  "ABC-1",
+		},
+		{
+			name:    "bare apostrophe",
+			content: `// @debtbomb(expire=2026-01-01, reason=don't ship)`,
+			reason:  "don't ship",
+		},
+		{
+			name:    "key value url",
+			content: `// @debtbomb // expire: 2026-01-01 // reason: see https://example.com/a#b // ticket: #1234`,
+			reason:  "see https://example.com/a#b",
+			ticket:  "#1234",
+		},
+		{
+			name:    "key value quoted",
+			content: `// @debtbomb expire: 2026-01-01 // reason: "a // b"`,
+			reason:  "a // b",
+		},
+		{
+			name:    "attribute line quoted",
+			content: "// @debtbomb\n// expire: 2026-01-01\n// reason: \"retry loop, remove later\"",
+			reason:  "retry loop, remove later",
+		},
+		{
+			name:    "unterminated quote",
+			content: `// @debtbomb(expire=2026-01-01, reason="retry loop)`,
+			problem: `unterminated quoted value for "reason"`,
+		},
+		{
+			name:    "unterminated quote on attribute line",
+			content: "// @debtbomb\n// expire: 2026-01-01\n// reason: 'oops",
+			problem: `unterminated quoted value for "reason"`,
+		},
+		{
+			name:    "unterminated list",
+			content: `// @debtbomb(expire=2026-01-01, owner=x`,
+			problem: "unterminated attribute list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseFile("test.go", strings.NewReader(tt.content+"\ncode()"))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			if tt.problem != "" {
+				if len(result.Diagnostics) != 1 || result.Diagnostics[0].Problem != tt.problem {
+					t.Fatalf("Expected problem %q, got %+v", tt.problem, result.Diagnostics)
+				}
+				return
+			}
+
+			if len(result.Diagnostics) != 0 {
+				t.Fatalf("Unexpected diagnostics %+v", result.Diagnostics)
+			}
+			if len(result.Bombs) != 1 {
+				t.Fatalf("Expected 1 bomb, got %d", len(result.Bombs))
+			}
+			b := result.Bombs[0]
+			if b.Reason != tt.reason {
+				t.Errorf("Expected reason %q, got %q", tt.reason, b.Reason)
+			}
+			if b.Ticket != tt.ticket {
+				t.Errorf("Expected ticket %q, got %q", tt.ticket, b.Ticket)
+			}
+		})
+	}
+}
+
+func TestParseCustomAttributes(t *testing.T) {
+	content := `
+	// @debtbomb(expire=2026-01-01, component=billing, tags="perf,security")
+	a()
+	// @debtbomb
+	//   expire: 2026-01-01
+	//   epic: ABC-1
+	//   TODO: not an attribute
+	b()
+	`
+	result, err := Parser{Keys: []string{"epic"}}.ParseFile("test.go", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	bombs := result.Bombs
+
+	if len(bombs) != 2 {
+		t.Fatalf("Expected 2 bombs, got %d", len(bombs))
+	}
+	if bombs[0].Attribute("component") != "billing" {
+		t.Errorf("Expected component billing, got %q", bombs[0].Attribute("component"))
+	}
+	if tags := bombs[0].Tags(); len(tags) != 2 || tags[0] != "perf" || tags[1] != "security" {
+		t.Errorf("Expected tags perf,security, got %v", tags)
+	}
+	if len(bombs[1].Attributes) != 1 || bombs[1].Attributes["epic"] != "ABC-1" {
+		t.Errorf("Expected only epic ABC-1, got %v", bombs[1].Attributes)
+	}
+}
+
+func TestParseUndeclaredKeyEndsAttributeLines(t *testing.T) {
+	content := `
+	// @debtbomb
+	//   expire: 2026-01-01
+	//   note: the retry loop hides a race
+	//   owner: payments
+	b()
+	`
+	bombs, err := Parse("test.go", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(bombs) != 1 {
+		t.Fatalf("Expected 1 bomb, got %d", len(bombs))
+	}
+	if len(bombs[0].Attributes) != 0 || bombs[0].Owner != "" {
+		t.Errorf("Expected the prose line to end the attributes, got %+v", bombs[0])
+	}
+}
+
+func TestParseIDIgnoresWhitespaceAndHonorsExplicitID(t *testing.T) {
+	a, _ := Parse("test.go", strings.NewReader("// @debtbomb(expire=2026-01-01, reason=hack)\nretry( 3 )"))
+	b, _ := Parse("test.go", strings.NewReader("// @debtbomb(expire=2026-01-01, reason=hack)\n\tretry(  3 )  "))
+	if len(a) != 1 || len(b) != 1 || a[0].ID != b[0].ID {
+		t.Fatalf("Expected reformatting to keep the ID")
+	}
+
+	c, _ := Parse("moved.go", strings.NewReader("// @debtbomb(expire=2026-01-01, id=retry-hack)\nretry(4)"))
+	if len(c) != 1 || c[0].ID != "retry-hack" {
+		t.Fatalf("Expected explicit ID retry-hack, got %+v", c)
+	}
+}
+
+func TestParseIDNumbersIdenticalBombs(t *testing.T) {
+	content := "// @debtbomb(expire=2026-01-01, reason=hack)\nretry(3)\n// @debtbomb(expire=2026-01-01, reason=hack)\nretry(3)\n"
+	bombs, _ := Parse("test.go", strings.NewReader(content))
+	if len(bombs) != 2 {
+		t.Fatalf("Expected 2 bombs, got %d", len(bombs))
+	}
+	if bombs[0].ID == bombs[1].ID {
+		t.Errorf("Expected distinct IDs, got %q twice", bombs[0].ID)
+	}
+
+	// The first keeps the ID it had on its own
+	single, _ := Parse("test.go", strings.NewReader("// @debtbomb(expire=2026-01-01, reason=hack)\nretry(3)\n"))
+	if len(single) != 1 || single[0].ID != bombs[0].ID {
+		t.Errorf("Expected the first bomb to keep its ID")
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	notebook := `{
+ "metadata": {"kernelspec": {"language": "python"}},
+ "cells": [
+  {"cell_type": "markdown", "source": ["# Notes\n", "<!-- @debtbomb(expire=2026-01-18, reason=prose) -->\n", "text"]},
+  {"cell_type": "code", "source": ["import x\n", "s = '# @debtbomb(expire=2020-01-01)'\n", "# @debtbomb(expire=2026-01-18, reason=code)\n", "x.hack()"]},
+  {"cell_type": "raw", "source": "# @debtbomb(expire=2020-01-01)"}
+ ]
+}`
+	tests := []struct {
+		file    string
+		content string
+		want    []string // cell:line:reason
+	}{
+		{"README.md", "---\ntitle: x # @debtbomb(expire=2026-01-18, reason=front)\n---\n<!-- @debtbomb(expire=2026-01-18, reason=prose) -->\ntext\n```html\n<!-- @debtbomb(expire=2020-01-01) -->\n```\n", []string{"0:2:front", "0:4:prose"}},
+		{"page.html", "<!-- <script> -->\n<script>\n// @debtbomb(expire=2026-01-18, reason=js)\nrun(\"<!-- @debtbomb(expire=2020-01-01) -->\")\n</script>\n<script type=\"application/json\">{\"a\": \"// @debtbomb(expire=2020-01-01)\"}</script>\n<style>/* @debtbomb(expire=2026-01-18, reason=css) */ a {}</style>", []string{"0:3:js", "0:7:css"}},
+		{"analysis.ipynb", notebook, []string{"1:2:prose", "2:3:code"}},
+		{"deploy.yaml", "url: http://x/#@debtbomb(expire=2020-01-01)\nrun: |\n  # @debtbomb(expire=2026-01-18, reason=yaml)\n  ./hack.sh\n", []string{"0:3:yaml"}},
+		{"Cargo.toml", "s = \"\"\"\n# @debtbomb(expire=2020-01-01)\n\"\"\"\n# @debtbomb(expire=2026-01-18, reason=toml)\nx = 1\n", []string{"0:4:toml"}},
+	}
+
+	for _, tt := range tests {
+		var result Result
+		var err error
+		if adapt := format.For(tt.file); adapt != nil {
+			sections, adaptErr := adapt([]byte(tt.content))
+			if adaptErr != nil {
+				t.Fatalf("%s: adapter failed: %v", tt.file, adaptErr)
+			}
+			result, err = ParseSections(tt.file, sections)
+		} else {
+			result, err = ParseFile(tt.file, strings.NewReader(tt.content))
+		}
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", tt.file, err)
+		}
+
+		var got []string
+		for _, b := range result.Bombs {
+			got = append(got, fmt.Sprintf("%d:%d:%s", b.Cell, b.Line, b.Reason))
+		}
+		sort.Strings(got)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: expected %v, got %v", tt.file, tt.want, got)
+		}
+		if len(result.Diagnostics) != 0 {
+			t.Errorf("%s: expected no diagnostics, got %+v", tt.file, result.Diagnostics)
+		}
+	}
+}
@@ -1,21 +1,29 @@
 package report
 
 import (
+	"fmt"
 	"path/filepath"
 	"sort"
 	"time"
 
+	"github.com/jobin-404/debtbomb/internal/clock"
 	"github.com/jobin-404/debtbomb/internal/model"
 )
 
 type Report struct {
-	TotalCount int            `json:"totalCount"`
-	ByOwner    []CountItem    `json:"byOwner"`
-	ByFolder   []CountItem    `json:"byFolder"`
-	ByReason   []CountItem    `json:"byReason"`
-	ByUrgency  UrgencyStats   `json:"byUrgency"`
-	Oldest     *model.DebtBomb `json:"oldest,omitempty"`
-	Newest     *model.DebtBomb `json:"newest,omitempty"`
+	TotalCount int          `json:"totalCount"`
+	ByOwner    []CountItem  `json:"byOwner"`
+	ByFolder   []CountItem  `json:"byFolder"`
+	ByReason   []CountItem  `json:"byReason"`
+	ByUrgency  UrgencyStats `json:"byUrgency"`
+	// GeneratedAt is the time urgency was measured against
+	GeneratedAt time.Time `json:"generatedAt"`
+	// ByAuthor is only filled in when bombs carry git blame data
+	ByAuthor []CountItem `json:"byAuthor,omitempty"`
+	// ByAttribute holds the counts for each key passed to GroupBy
+	ByAttribute map[string][]CountItem `json:"byAttribute,omitempty"`
+	Oldest      *model.DebtBomb        `json:"oldest,omitempty"`
+	Newest      *model.DebtBomb        `json:"newest,omitempty"`
 }
 
 type CountItem struct {
@@ -24,18 +32,21 @@ type CountItem struct {
 }
 
 type UrgencyStats struct {
-	Expired      int `json:"expired"`
-	Within30Days int `json:"within30Days"`
-	Within90Days int `json:"within90Days"`
+	Expired        int `json:"expired"`
+	Within30Days   int `json:"within30Days"`
+	Within90Days   int `json:"within90Days"`
 	MoreThan90Days int `json:"moreThan90Days"`
 }
 
-func Generate(bombs []model.DebtBomb) Report {
+// Generate aggregates bombs, measuring urgency against the clock
+func Generate(bombs []model.DebtBomb, clk clock.Clock) Report {
+	now := clk.Now()
 	report := Report{
-		TotalCount: len(bombs),
-		ByOwner:    make([]CountItem, 0),
-		ByFolder:   make([]CountItem, 0),
-		ByReason:   make([]CountItem, 0),
+		GeneratedAt: now,
+		TotalCount:  len(bombs),
+		ByOwner:     make([]CountItem, 0),
+		ByFolder:    make([]CountItem, 0),
+		ByReason:    make([]CountItem, 0),
 	}
 
 	if len(bombs) == 0 {
@@ -45,10 +56,7 @@ func Generate(bombs []model.DebtBomb) Report {
 	ownerCounts := make(map[string]int)
 	folderCounts := make(map[string]int)
 	reasonCounts := make(map[string]int)
-
-	today := time.Now().Truncate(24 * time.Hour)
-	day30 := today.AddDate(0, 0, 30)
-	day90 := today.AddDate(0, 0, 90)
+	authorCounts := make(map[string]int)
 
 	report.Oldest = &bombs[0]
 	report.Newest = &bombs[0]
@@ -71,14 +79,19 @@ func Generate(bombs []model.DebtBomb) Report {
 			reason = "(no reason)"
 		}
 		reasonCounts[reason]++
-		
+
+		if b.Git != nil {
+			authorCounts[b.Git.Author]++
+		}
+
 		if b.IsExpired {
 			report.ByUrgency.Expired++
 		} else {
-			if b.Expire.Before(day30) {
+			daysLeft := b.DaysLeft(now)
+			if daysLeft < 30 {
 				report.ByUrgency.Within30Days++
 			}
-			if b.Expire.Before(day90) {
+			if daysLeft < 90 {
 				report.ByUrgency.Within90Days++
 			} else {
 				report.ByUrgency.MoreThan90Days++
@@ -98,10 +111,34 @@ func Generate(bombs []model.DebtBomb) Report {
 	report.ByOwner = mapToSortedSlice(ownerCounts)
 	report.ByFolder = mapToSortedSlice(folderCounts)
 	report.ByReason = mapToSortedSlice(reasonCounts)
+	if len(authorCounts) > 0 {
+		report.ByAuthor = mapToSortedSlice(authorCounts)
+	}
 
 	return report
 }
 
+// GroupBy counts bombs by the value of a built-in field or custom attribute.
+// Bombs are counted once per tag when grouping by "tags".
+func GroupBy(bombs []model.DebtBomb, key string) []CountItem {
+	counts := make(map[string]int)
+	for _, b := range bombs {
+		var values []string
+		if key == "tags" {
+			values = b.Tags()
+		} else if v := b.Attribute(key); v != "" {
+			values = []string{v}
+		}
+		if len(values) == 0 {
+			values = []string{fmt.Sprintf("(no %s)", key)}
+		}
+		for _, v := range values {
+			counts[v]++
+		}
+	}
+	return mapToSortedSlice(counts)
+}
+
 func mapToSortedSlice(m map[string]int) []CountItem {
 	var s []CountItem
 	for k, v := range m {
@@ -0,0 +1,168 @@
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+// IgnoreFileName is read from the root and from every scanned directory
+const IgnoreFileName = ".debtbombignore"
+
+// ignorePattern is one line of an ignore file. Patterns follow .gitignore
+// syntax and apply to paths below the directory holding the file.
+type ignorePattern struct {
+	base     string // directory of the ignore file, slash separated, "" for the root
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// parseIgnorePattern parses one line of an ignore file. It returns false
+// for blank lines and comments.
+func parseIgnorePattern(base, line string) (ignorePattern, bool) {
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	p := ignorePattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	// A slash at the start or in the middle anchors the pattern to the
+	// directory of the ignore file; otherwise it matches at any depth
+	anchored := strings.Contains(line, "/")
+	p.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	if !anchored {
+		p.segments = append([]string{"**"}, p.segments...)
+	}
+	return p, true
+}
+
+// trimTrailingSpaces removes trailing spaces unless they are escaped
+func trimTrailingSpaces(line string) string {
+	line = strings.TrimRight(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-2] + " "
+	}
+	return line
+}
+
+// match reports whether relPath, slash separated and relative to the
+// scan root, matches the pattern
+func (p ignorePattern) match(relPath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.base != "" {
+		if !strings.HasPrefix(relPath, p.base+"/") {
+			return false
+		}
+		relPath = relPath[len(p.base)+1:]
+	}
+	return matchSegments(p.segments, strings.Split(relPath, "/"))
+}
+
+// matchSegments matches path components against pattern components, where
+// "**" matches any number of components
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				// A trailing "/**" matches everything inside, not the directory itself
+				return len(parts) > 0
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// ignoreMatcher holds the patterns of every ignore file loaded so far
+type ignoreMatcher struct {
+	fsys     fs.FS
+	patterns []ignorePattern
+	loaded   map[string]bool
+	// skip reports ignore files that exist but cannot be read
+	skip func(path, reason, detail string)
+}
+
+func newIgnoreMatcher(fsys fs.FS, skip func(path, reason, detail string)) *ignoreMatcher {
+	return &ignoreMatcher{fsys: fsys, loaded: make(map[string]bool), skip: skip}
+}
+
+// load reads the ignore file of dir, slash separated and relative to the
+// root, if it has not been read yet. Directories must be loaded parents
+// first so that deeper files take precedence.
+func (m *ignoreMatcher) load(dir string) {
+	if m.loaded[dir] {
+		return
+	}
+	m.loaded[dir] = true
+
+	name := path.Join(dir, IgnoreFileName)
+	file, err := m.fsys.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		m.skip(name, model.SkipUnreadable, err.Error())
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if p, ok := parseIgnorePattern(dir, scanner.Text()); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		m.skip(name, model.SkipUnreadable, err.Error())
+	}
+}
+
+// ignored reports whether relPath is ignored. The last matching pattern
+// wins, so a negation re-includes what an earlier pattern ignored.
+func (m *ignoreMatcher) ignored(relPath string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if p.match(relPath, isDir) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestIgnorePatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"base name at any depth", []string{"*.gen.go"}, "a/b/x.gen.go", false, true},
+		{"base name no match", []string{"*.gen.go"}, "a/b/x.go", false, false},
+		{"anchored at root", []string{"/build"}, "build", true, true},
+		{"anchored not nested", []string{"/build"}, "src/build", true, false},
+		{"middle slash anchors", []string{"src/generated"}, "src/generated", true, true},
+		{"middle slash not nested", []string{"src/generated"}, "lib/src/generated", true, false},
+		{"leading double star", []string{"**/generated"}, "a/b/generated", true, true},
+		{"leading double star at root", []string{"**/generated"}, "generated", true, true},
+		{"double star both sides", []string{"**/generated/**"}, "a/generated/x.go", false, true},
+		{"trailing double star excludes directory itself", []string{"logs/**"}, "logs", true, false},
+		{"trailing double star", []string{"logs/**"}, "logs/a/b.txt", false, true},
+		{"middle double star", []string{"a/**/z.go"}, "a/z.go", false, true},
+		{"middle double star deep", []string{"a/**/z.go"}, "a/b/c/z.go", false, true},
+		{"directory only matches directory", []string{"tmp/"}, "x/tmp", true, true},
+		{"directory only skips file", []string{"tmp/"}, "x/tmp", false, false},
+		{"negation re-includes", []string{"*.go", "!keep.go"}, "a/keep.go", false, false},
+		{"negation then ignore again", []string{"*.go", "!keep.go", "a/keep.go"}, "a/keep.go", false, true},
+		{"escaped bang", []string{`\!important.go`}, "!important.go", false, true},
+		{"comment", []string{"# main.go"}, "main.go", false, false},
+		{"character class", []string{"file[0-9].go"}, "file7.go", false, true},
+		{"question mark", []string{"v?.go"}, "v2.go", false, true},
+		{"star does not cross directories", []string{"a/*.go"}, "a/b/c.go", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newIgnoreMatcher(nil, nil)
+			for _, line := range tt.lines {
+				if p, ok := parseIgnorePattern("", line); ok {
+					m.patterns = append(m.patterns, p)
+				}
+			}
+			if got := m.ignored(tt.path, tt.isDir); got != tt.ignored {
+				t.Errorf("%v on %q: expected ignored=%v, got %v", tt.lines, tt.path, tt.ignored, got)
+			}
+		})
+	}
+}
+
+func TestScanNestedIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".debtbombignore":           "*.tmp.go\n/gen/\n",
+		"main.go":                   "",
+		"scratch.tmp.go":            "",
+		"gen/out.go":                "",
+		"pkgs/gen/kept.go":          "",
+		"pkgs/.debtbombignore":      "!keep.tmp.go\nlocal.go\n",
+		"pkgs/keep.tmp.go":          "",
+		"pkgs/drop.tmp.go":          "",
+		"pkgs/local.go":             "",
+		"other/local.go":            "",
+		"other/sub/.debtbombignore": "/only-here.go\n",
+		"other/sub/only-here.go":    "",
+		"other/only-here.go":        "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The ignore files themselves are scanned like any other file
+	want := []string{
+		".debtbombignore",
+		"other/sub/.debtbombignore",
+		"pkgs/.debtbombignore",
+		"main.go",
+		"other/local.go",
+		"other/only-here.go",
+		"pkgs/gen/kept.go",
+		"pkgs/keep.tmp.go",
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		got := scanAll(t, Config{RootPath: root})
+		assertPaths(t, got, want)
+	})
+
+	t.Run("file list", func(t *testing.T) {
+		var list []string
+		for name := range files {
+			list = append(list, filepath.FromSlash(name))
+		}
+		sort.Strings(list)
+		got := scanAll(t, Config{RootPath: root, Files: list})
+		assertPaths(t, got, want)
+	})
+}
+
+func scanAll(t *testing.T, config Config) []string {
+	t.Helper()
+	paths := make(chan string, 100)
+	errs := make(chan error, 1)
+	go func() { errs <- Scan(context.Background(), config, paths) }()
+
+	var got []string
+	for path := range paths {
+		got = append(got, path)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	return got
+}
+
+func assertPaths(t *testing.T, got, want []string) {
+	t.Helper()
+	sort.Strings(got)
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+}
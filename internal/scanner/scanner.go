@@ -1,19 +1,52 @@
 package scanner
 
 import (
-	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/jobin-404/debtbomb/internal/model"
 )
 
 // Config holds configuration for the scanner
 type Config struct {
 	RootPath string
+	// FS, when set, is scanned instead of the files under RootPath, e.g. a
+	// git revision or an archive
+	FS       fs.FS
 	Excluded []string
+	// Files, when set, lists the files to scan relative to the root instead
+	// of walking the whole tree. Exclusions still apply.
+	Files []string
+	// Paths, when set, lists the files and directories to scan relative to
+	// the root instead of the whole tree. Exclusions still apply, also to
+	// the parents of each path.
+	Paths []string
+	// IgnoredExtensions lists the file name suffixes to skip, matched case
+	// insensitively; nil means DefaultIgnoredExtensions
+	IgnoredExtensions []string
+	// MaxFileSize is the size in bytes above which files are skipped; 0
+	// means DefaultMaxFileSize and a negative value means no limit
+	MaxFileSize int64
+	// OnSkip, if set, is called for files that are left out because they
+	// cannot be read or are too large. Excluded files are not reported.
+	OnSkip func(model.SkippedFile)
 }
 
+// DefaultMaxFileSize is the size above which files are skipped unless
+// Config.MaxFileSize says otherwise
+const DefaultMaxFileSize = 1024 * 1024
+
+// StateDir holds debtbomb's own state and cache at the root of a scan. It
+// is never scanned: cached raw text would be found again as bombs.
+const StateDir = ".debtbomb"
+
 // DefaultExcluded returns the default list of excluded directories
 func DefaultExcluded() []string {
 	return []string{
@@ -30,119 +63,285 @@ func DefaultExcluded() []string {
 	}
 }
 
-// isIgnoredExt checks if the file extension is one we should ignore
-func isIgnoredExt(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".ico", ".webp", ".bmp", ".tiff",
-		".mp4", ".mov", ".avi", ".mkv", ".mp3", ".wav", ".flac", ".ogg":
-		return true
-	case ".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx":
-		return true
-	case ".zip", ".tar", ".gz", ".7z", ".rar", ".jar", ".war":
-		return true
-	case ".exe", ".dll", ".so", ".dylib", ".bin", ".ds_store",
-		".o", ".a", ".test", ".class", ".pyc":
-		return true
-	case ".log":
-		return true
-	case ".eot", ".ttf", ".woff", ".woff2":
-		return true
-	case ".min.js", ".min.css", ".lock":
-		return true
+// DefaultIgnoredExtensions returns the file name suffixes that are never
+// scanned. Suffixes may span several dots, such as ".min.js".
+func DefaultIgnoredExtensions() []string {
+	return []string{
+		".png", ".jpg", ".jpeg", ".gif", ".svg", ".ico", ".webp", ".bmp", ".tiff",
+		".mp4", ".mov", ".avi", ".mkv", ".mp3", ".wav", ".flac", ".ogg",
+		".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx",
+		".zip", ".tar", ".gz", ".7z", ".rar", ".jar", ".war",
+		".exe", ".dll", ".so", ".dylib", ".bin", ".ds_store",
+		".o", ".a", ".test", ".class", ".pyc",
+		".log",
+		".eot", ".ttf", ".woff", ".woff2",
+		".min.js", ".min.css", ".lock",
 	}
-	return false
 }
 
-// loadIgnoreFile reads .debtbombignore if it exists
-func loadIgnoreFile(root string) ([]string, error) {
-	path := filepath.Join(root, ".debtbombignore")
-	file, err := os.Open(path)
-	if os.IsNotExist(err) {
-		return nil, nil
+// filter decides which directories and files are skipped
+type filter struct {
+	excluded   map[string]bool
+	extensions map[string]bool
+	maxSize    int64
+	ignore     *ignoreMatcher
+}
+
+func newFilter(config Config) filter {
+	excludedMap := make(map[string]bool)
+	for _, dir := range config.Excluded {
+		excludedMap[dir] = true
+	}
+
+	extensions := config.IgnoredExtensions
+	if extensions == nil {
+		extensions = DefaultIgnoredExtensions()
 	}
-	if err != nil {
-		return nil, err
+	extensionMap := make(map[string]bool)
+	for _, ext := range extensions {
+		extensionMap[normalizeExt(ext)] = true
 	}
-	defer file.Close()
 
-	var patterns []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+	maxSize := config.MaxFileSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxFileSize
+	}
+
+	return filter{
+		excluded:   excludedMap,
+		extensions: extensionMap,
+		maxSize:    maxSize,
+		ignore:     newIgnoreMatcher(config.FS, config.skip),
+	}
+}
+
+// normalizeExt lowercases ext and adds the leading dot if it is missing
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// ignoredExt reports whether name ends in an ignored suffix. Every suffix
+// starting at a dot is tried, so ".min.js" matches "app.min.js".
+func (f filter) ignoredExt(name string) bool {
+	name = strings.ToLower(name)
+	for i := 0; i < len(name); i++ {
+		if name[i] == '.' && f.extensions[name[i:]] {
+			return true
 		}
-		patterns = append(patterns, line)
 	}
-	return patterns, scanner.Err()
+	return false
+}
+
+// tooLarge reports whether a file of size bytes is over the limit
+func (f filter) tooLarge(size int64) bool {
+	return f.maxSize > 0 && size > f.maxSize
+}
+
+func (config Config) skip(path, reason, detail string) {
+	if config.OnSkip != nil {
+		config.OnSkip(model.SkippedFile{File: path, Reason: reason, Detail: detail})
+	}
 }
 
-// Scan walks the directory tree and streams file paths to the provided channel
-func Scan(config Config, paths chan<- string) error {
+func (f filter) skipDir(relPath, name string) bool {
+	if f.excluded[name] || relPath == StateDir {
+		return true
+	}
+	return f.ignore.ignored(relPath, true)
+}
+
+func (f filter) skipFile(relPath, name string) bool {
+	if f.ignoredExt(name) {
+		return true
+	}
+	return f.ignore.ignored(relPath, false)
+}
+
+// Scan walks the directory tree and streams the paths of the files to
+// scan to the provided channel. Paths are slash separated and relative to
+// the root, as in io/fs. It stops with ctx.Err() when ctx is done.
+func Scan(ctx context.Context, config Config, paths chan<- string) error {
 	defer close(paths)
 
-	excludedMap := make(map[string]bool)
-	for _, dir := range config.Excluded {
-		excludedMap[dir] = true
+	if config.FS == nil {
+		root := config.RootPath
+		if root == "" {
+			root = "."
+		}
+		config.FS = os.DirFS(root)
 	}
 
-	ignorePatterns, _ := loadIgnoreFile(config.RootPath)
+	f := newFilter(config)
+	if config.Files != nil {
+		return scanFiles(ctx, config, f, config.Files, paths)
+	}
+	if config.Paths != nil {
+		return scanPaths(ctx, config, f, paths)
+	}
+	return walk(ctx, config, f, ".", paths)
+}
 
-	err := filepath.WalkDir(config.RootPath, func(path string, d fs.DirEntry, err error) error {
+// walk streams the files below dir
+func walk(ctx context.Context, config Config, f filter, dir string, paths chan<- string) error {
+	return fs.WalkDir(config.FS, dir, func(relPath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-
-		relPath, err := filepath.Rel(config.RootPath, path)
-		if err != nil {
-			relPath = path
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
 		if d.IsDir() {
-			if excludedMap[d.Name()] {
-				return filepath.SkipDir
+			if relPath == "." {
+				f.ignore.load("")
+				return nil
 			}
-			for _, p := range ignorePatterns {
-				pClean := strings.TrimSuffix(p, "/")
-				if matched, _ := filepath.Match(pClean, d.Name()); matched {
-					return filepath.SkipDir
-				}
-				if matched, _ := filepath.Match(pClean, relPath); matched {
-					return filepath.SkipDir
-				}
+			if f.skipDir(relPath, d.Name()) {
+				return fs.SkipDir
 			}
-			return nil
-		}
-		
-		if isIgnoredExt(path) {
+			// Ignore files in subdirectories apply below them
+			f.ignore.load(relPath)
 			return nil
 		}
 
-		for _, p := range ignorePatterns {
-			pClean := strings.TrimSuffix(p, "/")
-			// Match filename
-			if matched, _ := filepath.Match(pClean, d.Name()); matched {
-				return nil
-			}
-			// Match relative path
-			if matched, _ := filepath.Match(pClean, relPath); matched {
-				return nil
-			}
+		if f.skipFile(relPath, d.Name()) {
+			return nil
 		}
 
 		// Check size (requires Info())
 		info, err := d.Info()
 		if err != nil {
+			config.skip(relPath, model.SkipUnreadable, err.Error())
 			return nil
 		}
-		if info.Size() > 1024*1024 {
+		if f.tooLarge(info.Size()) {
+			config.skip(relPath, model.SkipTooLarge, fmt.Sprintf("%d bytes", info.Size()))
 			return nil
 		}
 
-		paths <- path
+		return send(ctx, paths, relPath)
+	})
+}
+
+// send passes path on unless ctx is done first
+func send(ctx context.Context, paths chan<- string, path string) error {
+	select {
+	case paths <- path:
 		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// scanFiles streams the listed files that exist and are not excluded
+func scanFiles(ctx context.Context, config Config, f filter, files []string, paths chan<- string) error {
+	for _, relPath := range files {
+		relPath = path.Clean(filepath.ToSlash(relPath))
+		if f.skipParents(relPath) || f.skipFile(relPath, path.Base(relPath)) {
+			continue
+		}
+
+		info, err := fs.Stat(config.FS, relPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Deleted since it was listed
+			continue
+		}
+		if err != nil {
+			config.skip(relPath, model.SkipUnreadable, err.Error())
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if f.tooLarge(info.Size()) {
+			config.skip(relPath, model.SkipTooLarge, fmt.Sprintf("%d bytes", info.Size()))
+			continue
+		}
+
+		if err := send(ctx, paths, relPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanPaths streams the listed files, and the files below the listed
+// directories. Paths inside another listed directory are scanned once.
+func scanPaths(ctx context.Context, config Config, f filter, paths chan<- string) error {
+	cleaned := make([]string, len(config.Paths))
+	for i, p := range config.Paths {
+		cleaned[i] = path.Clean(filepath.ToSlash(p))
+	}
+	// Parents sort before their children, so only "." needs moving
+	sort.Slice(cleaned, func(i, j int) bool {
+		if cleaned[i] == "." || cleaned[j] == "." {
+			return cleaned[j] != "."
+		}
+		return cleaned[i] < cleaned[j]
 	})
 
-	return err
-}
\ No newline at end of file
+	var scanned []string
+	for _, relPath := range cleaned {
+		covered := false
+		for _, dir := range scanned {
+			if Within(relPath, dir) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+
+		info, err := fs.Stat(config.FS, relPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			if err := scanFiles(ctx, config, f, []string{relPath}, paths); err != nil {
+				return err
+			}
+			continue
+		}
+
+		scanned = append(scanned, relPath)
+		if relPath != "." && f.skipParents(relPath) {
+			continue
+		}
+		if err := walk(ctx, config, f, relPath, paths); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipParents reports whether any parent directory of relPath is excluded.
+// Parents are visited top down so their ignore files load in order.
+func (f filter) skipParents(relPath string) bool {
+	f.ignore.load("")
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		if f.skipDir(dir, parts[i-1]) {
+			return true
+		}
+		f.ignore.load(dir)
+	}
+	return false
+}
+
+// Within reports whether relPath is dir or lies below it. Both are clean,
+// slash separated paths relative to the same directory.
+func Within(relPath, dir string) bool {
+	if dir == "." || relPath == dir {
+		return true
+	}
+	return strings.HasPrefix(relPath, dir+"/")
+}
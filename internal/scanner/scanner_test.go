@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+func TestScanExtensionsAndSizeLimit(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":    "package main\n",
+		"app.min.js": "x",
+		"App.MIN.JS": "x",
+		"build.log":  "x",
+		"dump.sql":   "x",
+		"big.txt":    strings.Repeat("x", 101),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var skipped []model.SkippedFile
+	got := scanAll(t, Config{
+		RootPath:          root,
+		IgnoredExtensions: []string{".min.js", "SQL"},
+		MaxFileSize:       100,
+		OnSkip:            func(s model.SkippedFile) { skipped = append(skipped, s) },
+	})
+	assertPaths(t, got, []string{"main.go", "build.log"})
+	if len(skipped) != 1 || filepath.Base(skipped[0].File) != "big.txt" || skipped[0].Reason != model.SkipTooLarge {
+		t.Errorf("Expected big.txt to be skipped as too large, got %+v", skipped)
+	}
+
+	// The defaults skip minified bundles too
+	got = scanAll(t, Config{RootPath: root, MaxFileSize: -1})
+	assertPaths(t, got, []string{"main.go", "dump.sql", "big.txt"})
+}
@@ -4,20 +4,48 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+
+	"github.com/jobin-404/debtbomb/internal/identity"
+	"github.com/jobin-404/debtbomb/internal/model"
 )
 
+const version = 2
+
+// Entry is what is remembered about a bomb that has a Jira ticket. The
+// fingerprint fields let the entry follow the bomb when its ID changes.
+type Entry struct {
+	Ticket  string `json:"ticket"`
+	File    string `json:"file,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Snippet string `json:"snippet,omitempty"` // identity.SnippetHash of the snippet
+}
+
+// Rekey records an entry that was moved to a new bomb ID
+type Rekey struct {
+	From   string
+	To     string
+	Ticket string
+}
+
 type State struct {
-	path string
-	mu   sync.RWMutex
-	Map  map[string]string
+	path    string
+	mu      sync.RWMutex
+	Entries map[string]Entry
+}
+
+type stateFile struct {
+	Version int              `json:"version"`
+	Bombs   map[string]Entry `json:"bombs"`
 }
 
 func Load(rootPath string) (*State, error) {
 	path := filepath.Join(rootPath, ".debtbomb", "jira-map.json")
 	s := &State{
-		path: path,
-		Map:  make(map[string]string),
+		path:    path,
+		Entries: make(map[string]Entry),
 	}
 
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -33,9 +61,22 @@ func Load(rootPath string) (*State, error) {
 		return s, nil
 	}
 
-	if err := json.Unmarshal(data, &s.Map); err != nil {
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err == nil && file.Version > 0 {
+		if file.Bombs != nil {
+			s.Entries = file.Bombs
+		}
+		return s, nil
+	}
+
+	// Older versions stored a flat map of bomb ID to ticket key
+	var legacy map[string]string
+	if err := json.Unmarshal(data, &legacy); err != nil {
 		return nil, err
 	}
+	for id, ticket := range legacy {
+		s.Entries[id] = Entry{Ticket: ticket}
+	}
 
 	return s, nil
 }
@@ -43,37 +84,134 @@ func Load(rootPath string) (*State, error) {
 func (s *State) GetTicket(bombID string) string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.Map[bombID]
+	return s.Entries[bombID].Ticket
 }
 
 func (s *State) SetTicket(bombID, ticket string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.Map[bombID] = ticket
+	e := s.Entries[bombID]
+	e.Ticket = ticket
+	s.Entries[bombID] = e
+}
+
+// Track refreshes the fingerprint of a bomb that has a ticket
+func (s *State) Track(b model.DebtBomb) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.Entries[b.ID]
+	if !ok {
+		return
+	}
+	e.File = b.File
+	e.Reason = b.Reason
+	e.Snippet = identity.SnippetHash(b.Snippet)
+	s.Entries[b.ID] = e
 }
 
 func (s *State) RemoveTicket(bombID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	delete(s.Map, bombID)
+	delete(s.Entries, bombID)
 }
 
 func (s *State) Snapshot() map[string]string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	copy := make(map[string]string)
-	for k, v := range s.Map {
-		copy[k] = v
+	for k, v := range s.Entries {
+		copy[k] = v.Ticket
 	}
 	return copy
 }
 
+// Migrate re-keys entries whose bomb is no longer found under its stored
+// ID but is recognized under a new one. A bomb is recognized by its
+// pre-normalization ID, or by matching at least two of file, reason and
+// snippet when exactly one bomb does. Empty reasons and snippets, and the
+// snippet of a bomb at the end of a file, do not count as a match.
+func (s *State) Migrate(bombs []model.DebtBomb) []Rekey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := make(map[string]bool)
+	for _, b := range bombs {
+		current[b.ID] = true
+	}
+
+	var orphans []string
+	for id := range s.Entries {
+		if !current[id] {
+			orphans = append(orphans, id)
+		}
+	}
+	sort.Strings(orphans)
+
+	var rekeyed []Rekey
+	for _, id := range orphans {
+		e := s.Entries[id]
+		match := -1
+		for i, b := range bombs {
+			if _, taken := s.Entries[b.ID]; taken {
+				continue
+			}
+			if identity.LegacyID(b.File, b.Reason, b.Snippet) == id {
+				match = i
+				break
+			}
+			if e.File == "" && e.Snippet == "" {
+				// Legacy entries carry no fingerprint
+				continue
+			}
+			if fingerprintScore(e, b) >= 2 {
+				if match != -1 {
+					// Ambiguous: leave the entry alone
+					match = -2
+					break
+				}
+				match = i
+			}
+		}
+		if match < 0 {
+			continue
+		}
+
+		b := bombs[match]
+		delete(s.Entries, id)
+		e.File = b.File
+		e.Reason = b.Reason
+		e.Snippet = identity.SnippetHash(b.Snippet)
+		s.Entries[b.ID] = e
+		rekeyed = append(rekeyed, Rekey{From: id, To: b.ID, Ticket: e.Ticket})
+	}
+
+	return rekeyed
+}
+
+// fingerprintScore counts the fingerprint parts an entry shares with b. An
+// empty reason or snippet is shared by too many bombs to count, and so is
+// the snippet of a bomb at the end of a file.
+func fingerprintScore(e Entry, b model.DebtBomb) int {
+	score := 0
+	if e.File == b.File {
+		score++
+	}
+	if b.Reason != "" && e.Reason == b.Reason {
+		score++
+	}
+	snippet := strings.TrimSpace(b.Snippet)
+	if snippet != "" && snippet != model.EOFSnippet && e.Snippet == identity.SnippetHash(b.Snippet) {
+		score++
+	}
+	return score
+}
+
 func (s *State) Save() error {
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	data, err := json.MarshalIndent(s.Map, "", "  ")
+	data, err := json.MarshalIndent(stateFile{Version: version, Bombs: s.Entries}, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -84,4 +222,4 @@ func (s *State) Save() error {
 	}
 
 	return os.WriteFile(s.path, data, 0644)
-}
\ No newline at end of file
+}
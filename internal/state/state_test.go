@@ -0,0 +1,110 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jobin-404/debtbomb/internal/identity"
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+func TestMigrateFollowsMovedBomb(t *testing.T) {
+	s := &State{Entries: map[string]Entry{
+		"old": {Ticket: "ENG-1", File: "a/retry.go", Reason: "retry loop", Snippet: identity.SnippetHash("retry(3)")},
+	}}
+
+	moved := model.DebtBomb{File: "b/retry.go", Reason: "retry loop", Snippet: "retry(3)"}
+	moved.ID = identity.ID(moved.File, moved.Reason, moved.Snippet)
+	other := model.DebtBomb{ID: "other", File: "b/other.go", Reason: "other", Snippet: "x()"}
+
+	rekeyed := s.Migrate([]model.DebtBomb{other, moved})
+
+	if len(rekeyed) != 1 || rekeyed[0].From != "old" || rekeyed[0].To != moved.ID {
+		t.Fatalf("Expected old to be re-keyed to %s, got %+v", moved.ID, rekeyed)
+	}
+	if s.GetTicket(moved.ID) != "ENG-1" {
+		t.Errorf("Expected ticket ENG-1 under the new ID, got %q", s.GetTicket(moved.ID))
+	}
+	if s.GetTicket("old") != "" {
+		t.Errorf("Expected old ID to be gone")
+	}
+}
+
+func TestMigrateLegacyState(t *testing.T) {
+	dir := t.TempDir()
+	b := model.DebtBomb{File: "main.go", Reason: "hack", Snippet: "doHack()  "}
+	b.ID = identity.ID(b.File, b.Reason, b.Snippet)
+	legacyID := identity.LegacyID(b.File, b.Reason, b.Snippet)
+
+	if err := os.MkdirAll(filepath.Join(dir, ".debtbomb"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := []byte(`{"` + legacyID + `": "ENG-2"}`)
+	if err := os.WriteFile(filepath.Join(dir, ".debtbomb", "jira-map.json"), legacy, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	s.Migrate([]model.DebtBomb{b})
+	if s.GetTicket(b.ID) != "ENG-2" {
+		t.Fatalf("Expected legacy ticket under the new ID, got %q", s.GetTicket(b.ID))
+	}
+
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	reloaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if reloaded.Entries[b.ID].Snippet != identity.SnippetHash(b.Snippet) {
+		t.Errorf("Expected fingerprint to be saved, got %+v", reloaded.Entries[b.ID])
+	}
+}
+
+func TestMigrateSkipsAmbiguousMatches(t *testing.T) {
+	s := &State{Entries: map[string]Entry{
+		"old": {Ticket: "ENG-3", File: "a.go", Reason: "dup", Snippet: identity.SnippetHash("x()")},
+	}}
+	bombs := []model.DebtBomb{
+		{ID: "n1", File: "b.go", Reason: "dup", Snippet: "x()"},
+		{ID: "n2", File: "c.go", Reason: "dup", Snippet: "x()"},
+	}
+
+	if rekeyed := s.Migrate(bombs); len(rekeyed) != 0 {
+		t.Fatalf("Expected no re-key, got %+v", rekeyed)
+	}
+}
+
+func TestMigrateIgnoresEmptyFingerprints(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		old  Entry
+		new  model.DebtBomb
+	}{
+		{
+			name: "no reason",
+			old:  Entry{Ticket: "ENG-4", File: "a.go", Snippet: identity.SnippetHash("deleted()")},
+			new:  model.DebtBomb{ID: "n1", File: "a.go", Snippet: "added()"},
+		},
+		{
+			name: "end of file",
+			old:  Entry{Ticket: "ENG-5", File: "a.go", Reason: "deleted", Snippet: identity.SnippetHash(model.EOFSnippet)},
+			new:  model.DebtBomb{ID: "n1", File: "a.go", Reason: "added", Snippet: model.EOFSnippet},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{Entries: map[string]Entry{"old": tt.old}}
+			if rekeyed := s.Migrate([]model.DebtBomb{tt.new}); len(rekeyed) != 0 {
+				t.Fatalf("Expected the new bomb not to inherit the deleted one's ticket, got %+v", rekeyed)
+			}
+			if s.GetTicket("old") != tt.old.Ticket {
+				t.Errorf("Expected the entry to stay under its old ID")
+			}
+		})
+	}
+}
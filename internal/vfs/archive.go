@@ -0,0 +1,146 @@
+package vfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// OpenArchive reads a .zip, .tar, .tar.gz or .tgz file into memory. Files
+// larger than maxSize keep only their size; a negative maxSize loads
+// everything. When every entry is inside one top-level directory, as in
+// release archives, that directory is stripped so that paths match the
+// repository.
+func OpenArchive(name string, maxSize int64) (*MemFS, error) {
+	lower := strings.ToLower(name)
+	var entries []archiveEntry
+	var err error
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		entries, err = readZip(name, maxSize)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		entries, err = readTar(name, true, maxSize)
+	case strings.HasSuffix(lower, ".tar"):
+		entries, err = readTar(name, false, maxSize)
+	default:
+		return nil, fmt.Errorf("%s: unsupported archive, expected .zip, .tar, .tar.gz or .tgz", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	for i := range entries {
+		entries[i].name = strings.TrimPrefix(entries[i].name, "./")
+	}
+	prefix := commonDir(entries)
+	m := New()
+	for _, e := range entries {
+		m.Add(strings.TrimPrefix(e.name, prefix), e.size, e.modTime, e.data)
+	}
+	return m, nil
+}
+
+// archiveEntry is a regular file read from an archive
+type archiveEntry struct {
+	name    string
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+func readTar(name string, gzipped bool, maxSize int64) ([]archiveEntry, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var entries []archiveEntry
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		e := archiveEntry{name: header.Name, size: header.Size, modTime: header.ModTime}
+		if fits(header.Size, maxSize) {
+			if e.data, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, e)
+	}
+}
+
+func readZip(name string, maxSize int64) ([]archiveEntry, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var entries []archiveEntry
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		size := int64(f.UncompressedSize64)
+		e := archiveEntry{name: f.Name, size: size, modTime: f.Modified}
+		if fits(size, maxSize) {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			e.data, err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func fits(size, maxSize int64) bool {
+	return maxSize < 0 || size <= maxSize
+}
+
+// commonDir returns the top-level directory, with its trailing slash,
+// that holds every entry, or "" if there is none
+func commonDir(entries []archiveEntry) string {
+	prefix := ""
+	for _, e := range entries {
+		dir, _, found := strings.Cut(e.name, "/")
+		if !found {
+			return ""
+		}
+		if prefix == "" {
+			prefix = dir + "/"
+		} else if prefix != dir+"/" {
+			return ""
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,112 @@
+package vfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var archiveFiles = map[string]string{
+	"proj-1.0/main.go":     "package main\n",
+	"proj-1.0/sub/util.go": "package sub\n",
+	"proj-1.0/big.txt":     "0123456789012345678901234567890\n",
+}
+
+func writeTarGz(t *testing.T, name string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, dir := range []string{"proj-1.0/", "proj-1.0/sub/"} {
+		if err := tw.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, content := range archiveFiles {
+		header := &tar.Header{Name: path, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content)), ModTime: time.Now()}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZip(t *testing.T, name string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for path, content := range archiveFiles {
+		w, err := zw.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenArchive(t *testing.T) {
+	dir := t.TempDir()
+	for name, write := range map[string]func(*testing.T, string){
+		"release.tar.gz": writeTarGz,
+		"release.zip":    writeZip,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			write(t, path)
+
+			m, err := OpenArchive(path, -1)
+			if err != nil {
+				t.Fatalf("OpenArchive failed: %v", err)
+			}
+			// The top-level directory is stripped
+			if err := fstest.TestFS(m, "main.go", "sub/util.go", "big.txt"); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err = OpenArchive(path, 20)
+			if err != nil {
+				t.Fatalf("OpenArchive failed: %v", err)
+			}
+			if info, err := m.Stat("big.txt"); err != nil || info.Size() != 32 {
+				t.Errorf("Expected big.txt with its size, got %v, %v", info, err)
+			}
+			if _, err := m.Open("big.txt"); !errors.Is(err, ErrNotLoaded) {
+				t.Errorf("Expected big.txt not to be loaded, got %v", err)
+			}
+			if data, err := fs.ReadFile(m, "main.go"); err != nil || string(data) != "package main\n" {
+				t.Errorf("Expected main.go, got %q, %v", data, err)
+			}
+		})
+	}
+
+	if _, err := OpenArchive(filepath.Join(dir, "release.rar"), -1); err == nil {
+		t.Error("Expected an error for an unsupported archive")
+	}
+}
@@ -0,0 +1,166 @@
+package vfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotLoaded is returned when opening a file whose content was left out
+// because it is larger than the limit the file system was built with
+var ErrNotLoaded = errors.New("file content not loaded")
+
+// MemFS is a read-only in-memory file system built from an archive or a
+// git tree. It implements fs.ReadDirFS and fs.StatFS.
+type MemFS struct {
+	files map[string]*memFile
+	dirs  map[string]map[string]fs.DirEntry
+}
+
+type memFile struct {
+	name    string
+	size    int64
+	modTime time.Time
+	data    []byte
+	loaded  bool
+}
+
+// New returns an empty MemFS
+func New() *MemFS {
+	return &MemFS{
+		files: make(map[string]*memFile),
+		dirs:  map[string]map[string]fs.DirEntry{".": {}},
+	}
+}
+
+// Add stores a regular file. A nil data adds the file with its size only,
+// and opening it fails with ErrNotLoaded; empty files need a non-nil data.
+func (m *MemFS) Add(name string, size int64, modTime time.Time, data []byte) {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if !fs.ValidPath(name) || name == "." {
+		return
+	}
+	f := &memFile{name: name, size: size, modTime: modTime, data: data, loaded: data != nil}
+	m.files[name] = f
+
+	child, entry := name, fs.DirEntry(fs.FileInfoToDirEntry(fileInfo{f}))
+	for {
+		dir := path.Dir(child)
+		entries, ok := m.dirs[dir]
+		if !ok {
+			entries = make(map[string]fs.DirEntry)
+			m.dirs[dir] = entries
+		}
+		entries[path.Base(child)] = entry
+		if ok || dir == "." {
+			return
+		}
+		child, entry = dir, fs.FileInfoToDirEntry(dirInfo{dir})
+	}
+}
+
+// Open implements fs.FS
+func (m *MemFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if f, ok := m.files[name]; ok {
+		if !f.loaded {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: ErrNotLoaded}
+		}
+		return &openFile{info: fileInfo{f}, Reader: bytes.NewReader(f.data)}, nil
+	}
+	if _, ok := m.dirs[name]; ok {
+		return &openDir{info: dirInfo{name}, entries: m.readDir(name)}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir implements fs.ReadDirFS
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if _, ok := m.dirs[name]; !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return m.readDir(name), nil
+}
+
+// Stat implements fs.StatFS
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	if f, ok := m.files[name]; ok {
+		return fileInfo{f}, nil
+	}
+	if _, ok := m.dirs[name]; ok {
+		return dirInfo{name}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *MemFS) readDir(name string) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(m.dirs[name]))
+	for _, e := range m.dirs[name] {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries
+}
+
+type fileInfo struct{ f *memFile }
+
+func (i fileInfo) Name() string       { return path.Base(i.f.name) }
+func (i fileInfo) Size() int64        { return i.f.size }
+func (i fileInfo) Mode() fs.FileMode  { return 0444 }
+func (i fileInfo) ModTime() time.Time { return i.f.modTime }
+func (i fileInfo) IsDir() bool        { return false }
+func (i fileInfo) Sys() any           { return nil }
+
+type dirInfo struct{ name string }
+
+func (i dirInfo) Name() string       { return path.Base(i.name) }
+func (i dirInfo) Size() int64        { return 0 }
+func (i dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (i dirInfo) ModTime() time.Time { return time.Time{} }
+func (i dirInfo) IsDir() bool        { return true }
+func (i dirInfo) Sys() any           { return nil }
+
+type openFile struct {
+	info fileInfo
+	*bytes.Reader
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openFile) Close() error               { return nil }
+
+type openDir struct {
+	info    dirInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *openDir) Close() error               { return nil }
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *openDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,209 @@
+package debtbomb
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/jobin-404/debtbomb/internal/clock"
+	"github.com/jobin-404/debtbomb/internal/engine"
+	"github.com/jobin-404/debtbomb/internal/model"
+	"github.com/jobin-404/debtbomb/internal/parser"
+	"github.com/jobin-404/debtbomb/internal/scanner"
+)
+
+// Clock tells the scan what time it is. Bombs are expired once the current
+// day is past their expire date.
+type Clock interface {
+	Now() time.Time
+}
+
+// FixedClock returns a Clock that always reports t
+func FixedClock(t time.Time) Clock {
+	return clock.Fixed(t)
+}
+
+// Options controls a scan. The zero value scans the current directory
+// with the default exclusions.
+type Options struct {
+	// Root is the directory to scan; "" means the current directory.
+	// Reported file paths start with Root unless Base is set.
+	Root string
+	// FS, when set, is scanned instead of the files under Root, e.g. an
+	// embed.FS or fstest.MapFS. Its paths are reported as if under Root.
+	FS fs.FS
+	// Rev, when set, scans the files under Root as they are in this git
+	// revision without touching the work tree
+	Rev string
+	// Archive, when set, scans the files in this .zip, .tar, .tar.gz or
+	// .tgz file as if they were under Root. A single top-level directory
+	// holding every file is stripped.
+	Archive string
+	// Paths, when set, limits the scan to these files and directories,
+	// relative to Root
+	Paths []string
+	// Base, when set, makes reported file paths relative to this directory.
+	// Bomb IDs include the path, so keep it the same across runs.
+	Base string
+	// Excludes lists directory names to skip at any depth. nil means
+	// DefaultExcludes; use an empty slice to scan everything.
+	Excludes []string
+	// ExcludeExtensions lists file name suffixes to skip, such as ".min.js".
+	// nil means DefaultExcludeExtensions; use an empty slice to scan every
+	// file that does not look binary.
+	ExcludeExtensions []string
+	// MaxFileSize is the size in bytes above which files are skipped; 0
+	// means 1 MiB and a negative value means no limit
+	MaxFileSize int64
+	// GitIgnore scans only the files git tracks or would track, honoring
+	// .gitignore. Outside a git work tree the directory is walked. It does
+	// not apply to FS, Rev or Archive.
+	GitIgnore bool
+	// Git fills in DebtBomb.Git from git blame, of Rev when it is set. It
+	// does not apply to FS or Archive.
+	Git bool
+	// Workers is the number of files parsed in parallel; 0 picks a default
+	// based on the CPU count
+	Workers int
+	// Clock decides which bombs are expired; nil means the system clock
+	Clock Clock
+	// Location is the time zone of expire dates without a tz attribute;
+	// nil means UTC. A date expires at the start of the following day.
+	Location *time.Location
+	// Attributes lists the custom attribute keys read from the comment
+	// lines after a bare marker. Lines with other keys are taken as prose.
+	// Custom keys inside the marker itself are always read.
+	Attributes []string
+	// RelativeBase is the date that relative expiries such as +90d count
+	// from when Git is off or the line is not committed. When it is zero,
+	// those bombs are reported as diagnostics.
+	RelativeBase time.Time
+	// Cache reuses the parse results that the debtbomb CLI stores in
+	// Root/.debtbomb/cache, and writes that cache back. It only applies to
+	// scans of the work tree.
+	Cache bool
+}
+
+// Result is the outcome of a scan
+type Result struct {
+	// Bombs are sorted by expire date, then file and line
+	Bombs       []DebtBomb
+	Diagnostics []Diagnostic
+	Skipped     []SkippedFile
+}
+
+// Expired returns the bombs that are past their expire date
+func (r *Result) Expired() []DebtBomb {
+	var expired []DebtBomb
+	for _, b := range r.Bombs {
+		if b.IsExpired {
+			expired = append(expired, b)
+		}
+	}
+	return expired
+}
+
+// DefaultExcludes returns the directory names skipped by default
+func DefaultExcludes() []string {
+	return scanner.DefaultExcluded()
+}
+
+// DefaultExcludeExtensions returns the file name suffixes skipped by default
+func DefaultExcludeExtensions() []string {
+	return scanner.DefaultIgnoredExtensions()
+}
+
+// Scan finds every @debtbomb marker under opts.Root. It stops early and
+// returns ctx.Err() when ctx is done.
+func Scan(ctx context.Context, opts Options) (*Result, error) {
+	result, err := engine.Run(ctx, opts.engine())
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Bombs:       fromModelBombs(result.Bombs),
+		Diagnostics: fromModelDiagnostics(result.Diagnostics),
+		Skipped:     fromModelSkipped(result.Skipped),
+	}, nil
+}
+
+// Summary describes a streamed scan once every bomb has been sent
+type Summary struct {
+	// Bombs and Expired count the bombs that were sent
+	Bombs   int
+	Expired int
+	// Diagnostics includes bombs that share an ID with an earlier one
+	Diagnostics []Diagnostic
+	Skipped     []SkippedFile
+}
+
+// Stream finds every @debtbomb marker under opts.Root like Scan, but sends
+// each bomb to bombs as soon as its file is parsed instead of collecting
+// them. Bombs arrive in no particular order. bombs is closed when the scan
+// ends, also on errors, so it can be ranged over while Stream runs in
+// another goroutine. bombs is closed before Stream returns, so callers must
+// wait for Stream to return before using the Summary or error.
+func Stream(ctx context.Context, opts Options, bombs chan<- DebtBomb) (*Summary, error) {
+	defer close(bombs)
+
+	found := make(chan model.DebtBomb)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for b := range found {
+			select {
+			case bombs <- fromModel(b):
+			case <-ctx.Done():
+			}
+		}
+	}()
+	summary, err := engine.Stream(ctx, opts.engine(), found)
+	<-forwarded
+	if err != nil {
+		return nil, err
+	}
+	return &Summary{
+		Bombs:       summary.Bombs,
+		Expired:     summary.Expired,
+		Diagnostics: fromModelDiagnostics(summary.Diagnostics),
+		Skipped:     fromModelSkipped(summary.Skipped),
+	}, nil
+}
+
+// ParseFile reads the markers of a single file. The filename selects the
+// comment syntax and is recorded in the result. Relative expire dates such
+// as "+90d" are left unresolved (zero Expire), and IsExpired is not set;
+// use Scan for both.
+func ParseFile(filename string, r io.Reader) ([]DebtBomb, []Diagnostic, error) {
+	result, err := parser.ParseFile(filename, r)
+	return fromModelBombs(result.Bombs), fromModelDiagnostics(result.Diagnostics), err
+}
+
+func (opts Options) engine() engine.Options {
+	root := opts.Root
+	if root == "" {
+		root = "."
+	}
+
+	return engine.Options{
+		RootPath:  root,
+		FS:        opts.FS,
+		Rev:       opts.Rev,
+		Archive:   opts.Archive,
+		Paths:     opts.Paths,
+		Base:      opts.Base,
+		Excluded:  opts.Excludes,
+		GitIgnore: opts.GitIgnore,
+		Git:       opts.Git,
+		Workers:   opts.Workers,
+		Clock:     opts.Clock,
+		Location:  opts.Location,
+		Cache:     opts.Cache,
+
+		Attributes:        opts.Attributes,
+		RelativeBase:      opts.RelativeBase,
+		IgnoredExtensions: opts.ExcludeExtensions,
+		MaxFileSize:       opts.MaxFileSize,
+	}
+}
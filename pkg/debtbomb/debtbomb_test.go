@@ -0,0 +1,98 @@
+package debtbomb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+func TestScanWithClock(t *testing.T) {
+	root := t.TempDir()
+	src := "// @debtbomb(expire=2026-01-31)\nfunc a() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		today   time.Time
+		expired bool
+	}{
+		{time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC), false},
+		{time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), true},
+	} {
+		result, err := Scan(context.Background(), Options{Root: root, Clock: FixedClock(tt.today), Workers: 1})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if len(result.Bombs) != 1 {
+			t.Fatalf("Expected 1 bomb, got %d", len(result.Bombs))
+		}
+		if got := len(result.Expired()) == 1; got != tt.expired {
+			t.Errorf("On %s expected expired=%v, got %v", tt.today.Format("2006-01-02"), tt.expired, got)
+		}
+	}
+}
+
+func TestScanCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Scan(ctx, Options{Root: t.TempDir()}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestBombRoundTrip(t *testing.T) {
+	b := model.DebtBomb{
+		ID: "a1b2c3d4", File: "nb.ipynb", Line: 2, Column: 3, Cell: 4,
+		Expire: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), ExpireExpr: "2026-01-31",
+		Owner: "payments", Ticket: "PAY-1", Reason: "retry loop", Severity: "high",
+		TimeZone: "Europe/Berlin", RawText: "// @debtbomb", Snippet: "func a() {}",
+		Attributes: map[string]string{"component": "billing"},
+		Git:        &model.GitInfo{Author: "Ada", AuthorEmail: "ada@example.com", Commit: "0123456789", Date: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		IsExpired:  true,
+	}
+	if got := fromModel(b).model(); !reflect.DeepEqual(got, b) {
+		t.Errorf("Round trip changed the bomb:\n got %+v\nwant %+v", got, b)
+	}
+	if got, want := fromModel(b).Location(), b.Location(); got != want {
+		t.Errorf("Expected location %q, got %q", want, got)
+	}
+}
+
+func TestStream(t *testing.T) {
+	root := t.TempDir()
+	src := "// @debtbomb(expire=2026-01-31, owner=payments)\nfunc a() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bombs := make(chan DebtBomb)
+	var summary *Summary
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		summary, err = Stream(context.Background(), Options{Root: root, Clock: FixedClock(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))}, bombs)
+	}()
+
+	var got []DebtBomb
+	for b := range bombs {
+		got = append(got, b)
+	}
+	<-done
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Owner != "payments" || !got[0].IsExpired {
+		t.Errorf("Expected one expired bomb owned by payments, got %+v", got)
+	}
+	if summary.Bombs != 1 || summary.Expired != 1 {
+		t.Errorf("Expected 1 bomb and 1 expired in the summary, got %+v", summary)
+	}
+}
@@ -0,0 +1,18 @@
+// Package debtbomb scans source trees for @debtbomb markers: comments that
+// declare technical debt with an expiry date.
+//
+// It is the library behind the debtbomb command. Scan walks a directory
+// and returns every marker found, along with diagnostics for malformed
+// markers and the files that could not be read; Stream hands out each
+// marker as soon as its file is parsed instead. GenerateReport aggregates
+// the result, and the Write functions render it the way the command does.
+//
+// # Stability
+//
+// This package follows semantic versioning. Exported identifiers are not
+// removed or changed incompatibly within a major version; fields may be
+// added to structs, so construct them with field names. The text output of
+// WriteTable, WriteCheckReport and WriteReport is meant for people and may
+// change in any release; the JSON written by WriteJSON and WriteReportJSON
+// only gains fields.
+package debtbomb
@@ -0,0 +1,88 @@
+package debtbomb_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jobin-404/debtbomb/pkg/debtbomb"
+)
+
+func ExampleScan() {
+	result, err := debtbomb.Scan(context.Background(), debtbomb.Options{
+		Root:     ".",
+		Excludes: append(debtbomb.DefaultExcludes(), "testdata"),
+		Clock:    debtbomb.FixedClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, b := range result.Expired() {
+		fmt.Printf("%s:%d expired on %s (%s)\n", b.File, b.Line, b.Expire.Format("2006-01-02"), b.Owner)
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("%d files could not be scanned\n", len(result.Skipped))
+	}
+}
+
+func ExampleStream() {
+	bombs := make(chan debtbomb.DebtBomb)
+	var summary *debtbomb.Summary
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		summary, err = debtbomb.Stream(context.Background(), debtbomb.Options{Root: "."}, bombs)
+	}()
+
+	// Bombs arrive while the rest of the tree is still being scanned
+	for b := range bombs {
+		fmt.Println(b.Location(), b.Owner)
+	}
+	// bombs is closed before Stream returns its summary
+	<-done
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d bombs, %d expired\n", summary.Bombs, summary.Expired)
+}
+
+func ExampleParseFile() {
+	src := `package billing
+
+// @debtbomb(expire=2026-03-31, owner=payments, component=billing)
+// Retry loop until the provider fixes idempotency keys
+func charge() {}
+`
+	bombs, diagnostics, err := debtbomb.ParseFile("billing.go", strings.NewReader(src))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, b := range bombs {
+		fmt.Println(b.File, b.Line, b.Expire.Format("2006-01-02"), b.Owner, b.Attribute("component"))
+	}
+	fmt.Println(len(diagnostics), "diagnostics")
+	// Output:
+	// billing.go 3 2026-03-31 payments billing
+	// 0 diagnostics
+}
+
+func ExampleGenerateReport() {
+	result, err := debtbomb.Scan(context.Background(), debtbomb.Options{Root: "."})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := debtbomb.GenerateReport(result.Bombs)
+	r.ByAttribute = map[string][]debtbomb.CountItem{
+		"component": debtbomb.GroupBy(result.Bombs, "component"),
+	}
+	if err := debtbomb.WriteReportJSON(os.Stdout, r); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,238 @@
+package debtbomb
+
+import (
+	"time"
+
+	"github.com/jobin-404/debtbomb/internal/model"
+)
+
+// DebtBomb is a single @debtbomb marker
+type DebtBomb struct {
+	ID     string `json:"id"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Cell is the 1-based notebook cell holding the marker, in which case
+	// Line counts from the start of the cell. It is 0 for other files.
+	Cell   int       `json:"cell,omitempty"`
+	Expire time.Time `json:"expire"`
+	// ExpireExpr is the expire attribute as written, e.g. "+90d" or "2026-Q3"
+	ExpireExpr string `json:"expireExpr,omitempty"`
+	Owner      string `json:"owner,omitempty"`
+	Ticket     string `json:"ticket,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	Severity   string `json:"severity,omitempty"`
+	// TimeZone is the tz attribute, an IANA name such as "Europe/Berlin"
+	TimeZone string `json:"tz,omitempty"`
+	RawText  string `json:"rawText"`
+	Snippet  string `json:"snippet"`
+	// Attributes holds every attribute without a dedicated field, e.g. component=billing
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Git is set when the bomb was enriched from git blame
+	Git       *GitInfo `json:"git,omitempty"`
+	IsExpired bool     `json:"isExpired"`
+}
+
+// Attribute returns a built-in field or custom attribute by key
+func (b DebtBomb) Attribute(key string) string {
+	return b.model().Attribute(key)
+}
+
+// Deadline returns the moment the bomb explodes: the start of the day
+// after the expire date, or the expire instant for an RFC 3339 timestamp
+func (b DebtBomb) Deadline() time.Time {
+	return b.model().Deadline()
+}
+
+// HasExpireTime reports whether the expire attribute is an RFC 3339
+// timestamp rather than a date
+func (b DebtBomb) HasExpireTime() bool {
+	return b.model().HasExpireTime()
+}
+
+// ExpiredAt reports whether the bomb has exploded at now
+func (b DebtBomb) ExpiredAt(now time.Time) bool {
+	return b.model().ExpiredAt(now)
+}
+
+// DaysLeft returns the number of calendar days from now until the expire
+// date: 0 on the expire date itself and negative once it has passed
+func (b DebtBomb) DaysLeft(now time.Time) int {
+	return b.model().DaysLeft(now)
+}
+
+// Location returns "file:line", or "file:cell N:line" inside a notebook
+func (b DebtBomb) Location() string {
+	return b.model().Location()
+}
+
+// Tags returns the comma or space separated values of the tags attribute
+func (b DebtBomb) Tags() []string {
+	return b.model().Tags()
+}
+
+// GitInfo describes the commit that introduced a bomb
+type GitInfo struct {
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"authorEmail"`
+	Commit      string    `json:"commit"`
+	Date        time.Time `json:"date"`
+}
+
+// ShortCommit returns the abbreviated commit hash
+func (g GitInfo) ShortCommit() string {
+	return model.GitInfo(g).ShortCommit()
+}
+
+// Diagnostic describes a malformed @debtbomb marker
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Cell    int    `json:"cell,omitempty"`
+	Problem string `json:"problem"`
+	RawText string `json:"rawText"`
+}
+
+// Location returns "file:line", or "file:cell N:line" inside a notebook
+func (d Diagnostic) Location() string {
+	return model.Diagnostic(d).Location()
+}
+
+// SkippedFile is a file the scan did not read, or only read in part
+type SkippedFile struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Reasons reported in SkippedFile.Reason
+const (
+	SkipTooLarge    = model.SkipTooLarge
+	SkipUnreadable  = model.SkipUnreadable
+	SkipLineTooLong = model.SkipLineTooLong
+	SkipBinary      = model.SkipBinary
+	SkipMalformed   = model.SkipMalformed
+)
+
+// The types above are copies of the internal ones so that the internal
+// model can change without breaking callers; these helpers convert at the
+// package boundary.
+
+func fromModel(b model.DebtBomb) DebtBomb {
+	out := DebtBomb{
+		ID:         b.ID,
+		File:       b.File,
+		Line:       b.Line,
+		Column:     b.Column,
+		Cell:       b.Cell,
+		Expire:     b.Expire,
+		ExpireExpr: b.ExpireExpr,
+		Owner:      b.Owner,
+		Ticket:     b.Ticket,
+		Reason:     b.Reason,
+		Severity:   b.Severity,
+		TimeZone:   b.TimeZone,
+		RawText:    b.RawText,
+		Snippet:    b.Snippet,
+		Attributes: b.Attributes,
+		IsExpired:  b.IsExpired,
+	}
+	if b.Git != nil {
+		git := GitInfo(*b.Git)
+		out.Git = &git
+	}
+	return out
+}
+
+func (b DebtBomb) model() model.DebtBomb {
+	out := model.DebtBomb{
+		ID:         b.ID,
+		File:       b.File,
+		Line:       b.Line,
+		Column:     b.Column,
+		Cell:       b.Cell,
+		Expire:     b.Expire,
+		ExpireExpr: b.ExpireExpr,
+		Owner:      b.Owner,
+		Ticket:     b.Ticket,
+		Reason:     b.Reason,
+		Severity:   b.Severity,
+		TimeZone:   b.TimeZone,
+		RawText:    b.RawText,
+		Snippet:    b.Snippet,
+		Attributes: b.Attributes,
+		IsExpired:  b.IsExpired,
+	}
+	if b.Git != nil {
+		git := model.GitInfo(*b.Git)
+		out.Git = &git
+	}
+	return out
+}
+
+func fromModelBombs(bombs []model.DebtBomb) []DebtBomb {
+	if bombs == nil {
+		return nil
+	}
+	out := make([]DebtBomb, len(bombs))
+	for i, b := range bombs {
+		out[i] = fromModel(b)
+	}
+	return out
+}
+
+func modelBombs(bombs []DebtBomb) []model.DebtBomb {
+	if bombs == nil {
+		return nil
+	}
+	out := make([]model.DebtBomb, len(bombs))
+	for i, b := range bombs {
+		out[i] = b.model()
+	}
+	return out
+}
+
+func fromModelDiagnostics(diagnostics []model.Diagnostic) []Diagnostic {
+	if diagnostics == nil {
+		return nil
+	}
+	out := make([]Diagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		out[i] = Diagnostic(d)
+	}
+	return out
+}
+
+func modelDiagnostics(diagnostics []Diagnostic) []model.Diagnostic {
+	if diagnostics == nil {
+		return nil
+	}
+	out := make([]model.Diagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		out[i] = model.Diagnostic(d)
+	}
+	return out
+}
+
+func fromModelSkipped(skipped []model.SkippedFile) []SkippedFile {
+	if skipped == nil {
+		return nil
+	}
+	out := make([]SkippedFile, len(skipped))
+	for i, s := range skipped {
+		out[i] = SkippedFile(s)
+	}
+	return out
+}
+
+func modelSkipped(skipped []SkippedFile) []model.SkippedFile {
+	if skipped == nil {
+		return nil
+	}
+	out := make([]model.SkippedFile, len(skipped))
+	for i, s := range skipped {
+		out[i] = model.SkippedFile(s)
+	}
+	return out
+}
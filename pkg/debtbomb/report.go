@@ -0,0 +1,167 @@
+package debtbomb
+
+import (
+	"io"
+	"time"
+
+	"github.com/jobin-404/debtbomb/internal/clock"
+	"github.com/jobin-404/debtbomb/internal/output"
+	"github.com/jobin-404/debtbomb/internal/report"
+)
+
+// Report holds aggregated statistics about a set of bombs
+type Report struct {
+	TotalCount int          `json:"totalCount"`
+	ByOwner    []CountItem  `json:"byOwner"`
+	ByFolder   []CountItem  `json:"byFolder"`
+	ByReason   []CountItem  `json:"byReason"`
+	ByUrgency  UrgencyStats `json:"byUrgency"`
+	// GeneratedAt is the time urgency was measured against
+	GeneratedAt time.Time `json:"generatedAt"`
+	// ByAuthor is only filled in when bombs carry git blame data
+	ByAuthor []CountItem `json:"byAuthor,omitempty"`
+	// ByAttribute holds the counts for each key passed to GroupBy
+	ByAttribute map[string][]CountItem `json:"byAttribute,omitempty"`
+	Oldest      *DebtBomb              `json:"oldest,omitempty"`
+	Newest      *DebtBomb              `json:"newest,omitempty"`
+}
+
+// CountItem is one row of a Report section
+type CountItem struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// UrgencyStats counts bombs by how soon they expire
+type UrgencyStats struct {
+	Expired        int `json:"expired"`
+	Within30Days   int `json:"within30Days"`
+	Within90Days   int `json:"within90Days"`
+	MoreThan90Days int `json:"moreThan90Days"`
+}
+
+// GenerateReport aggregates bombs by owner, folder, reason, author and
+// urgency, measured against the system clock
+func GenerateReport(bombs []DebtBomb) Report {
+	return fromReport(report.Generate(modelBombs(bombs), clock.System()))
+}
+
+// GenerateReportAt is GenerateReport with urgency measured against clk
+func GenerateReportAt(bombs []DebtBomb, clk Clock) Report {
+	return fromReport(report.Generate(modelBombs(bombs), clock.OrSystem(clk)))
+}
+
+// GroupBy counts bombs by the value of a built-in field or custom
+// attribute. Bombs are counted once per tag when grouping by "tags".
+func GroupBy(bombs []DebtBomb, key string) []CountItem {
+	return fromCountItems(report.GroupBy(modelBombs(bombs), key))
+}
+
+// WriteJSON writes a scan result in the format of "debtbomb list --json"
+func WriteJSON(w io.Writer, r *Result) error {
+	return output.WriteJSON(w, modelBombs(r.Bombs), modelDiagnostics(r.Diagnostics), modelSkipped(r.Skipped))
+}
+
+// WriteTable writes bombs as the table printed by "debtbomb list", with
+// the time left measured against the system clock
+func WriteTable(w io.Writer, bombs []DebtBomb) {
+	output.WriteTable(w, modelBombs(bombs), clock.System())
+}
+
+// WriteTableAt is WriteTable with the time left measured against clk
+func WriteTableAt(w io.Writer, bombs []DebtBomb, clk Clock) {
+	output.WriteTable(w, modelBombs(bombs), clock.OrSystem(clk))
+}
+
+// WriteCheckReport writes expired bombs, bombs expiring within warnDays and
+// diagnostics as printed by "debtbomb check"
+func WriteCheckReport(w io.Writer, expired, warning []DebtBomb, warnDays int, diagnostics []Diagnostic) {
+	output.WriteCheckReport(w, modelBombs(expired), modelBombs(warning), warnDays, modelDiagnostics(diagnostics))
+}
+
+// WriteReport writes a report as printed by "debtbomb report". The time
+// left is measured against Report.GeneratedAt.
+func WriteReport(w io.Writer, r Report) {
+	output.WriteReport(w, r.report())
+}
+
+// WriteReportJSON writes a report in the format of "debtbomb report --json"
+func WriteReportJSON(w io.Writer, r Report) error {
+	return output.WriteReportJSON(w, r.report())
+}
+
+func fromReport(r report.Report) Report {
+	out := Report{
+		TotalCount:  r.TotalCount,
+		ByOwner:     fromCountItems(r.ByOwner),
+		ByFolder:    fromCountItems(r.ByFolder),
+		ByReason:    fromCountItems(r.ByReason),
+		ByUrgency:   UrgencyStats(r.ByUrgency),
+		GeneratedAt: r.GeneratedAt,
+		ByAuthor:    fromCountItems(r.ByAuthor),
+	}
+	if r.ByAttribute != nil {
+		out.ByAttribute = make(map[string][]CountItem, len(r.ByAttribute))
+		for key, items := range r.ByAttribute {
+			out.ByAttribute[key] = fromCountItems(items)
+		}
+	}
+	if r.Oldest != nil {
+		oldest := fromModel(*r.Oldest)
+		out.Oldest = &oldest
+	}
+	if r.Newest != nil {
+		newest := fromModel(*r.Newest)
+		out.Newest = &newest
+	}
+	return out
+}
+
+func (r Report) report() report.Report {
+	out := report.Report{
+		TotalCount:  r.TotalCount,
+		ByOwner:     countItems(r.ByOwner),
+		ByFolder:    countItems(r.ByFolder),
+		ByReason:    countItems(r.ByReason),
+		ByUrgency:   report.UrgencyStats(r.ByUrgency),
+		GeneratedAt: r.GeneratedAt,
+		ByAuthor:    countItems(r.ByAuthor),
+	}
+	if r.ByAttribute != nil {
+		out.ByAttribute = make(map[string][]report.CountItem, len(r.ByAttribute))
+		for key, items := range r.ByAttribute {
+			out.ByAttribute[key] = countItems(items)
+		}
+	}
+	if r.Oldest != nil {
+		oldest := r.Oldest.model()
+		out.Oldest = &oldest
+	}
+	if r.Newest != nil {
+		newest := r.Newest.model()
+		out.Newest = &newest
+	}
+	return out
+}
+
+func fromCountItems(items []report.CountItem) []CountItem {
+	if items == nil {
+		return nil
+	}
+	out := make([]CountItem, len(items))
+	for i, item := range items {
+		out[i] = CountItem(item)
+	}
+	return out
+}
+
+func countItems(items []CountItem) []report.CountItem {
+	if items == nil {
+		return nil
+	}
+	out := make([]report.CountItem, len(items))
+	for i, item := range items {
+		out[i] = report.CountItem(item)
+	}
+	return out
+}